@@ -1,33 +1,103 @@
 package algorithm
 
 import (
+	"context"
+	"fmt"
 	"math"
 
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
 )
 
 // BuildAnonGraph builds a graph from the table for anonymization.
 func BuildAnonGraph(table *model.Table, k int) (graph.Directed, error) {
-	costGraph, err := BuildCostGraph(table)
+	return BuildAnonGraphContext(context.Background(), table, k, nil)
+}
+
+// BuildAnonGraphContext builds a graph from the table for anonymization.
+// The construction is aborted with an error, when the context is done.
+func BuildAnonGraphContext(ctx context.Context, table *model.Table, k int, opts *Options) (graph.Directed, error) {
+	costs, err := buildCostSource(ctx, table, opts)
 	if err != nil {
 		return nil, err
 	}
-	g := buildEmptyAnonGraph(table)
+	return buildAnonGraph(ctx, costs, len(table.GetRows()), k, opts)
+}
+
+// BuildAnonGraphFromCostsContext builds a graph for anonymization from a cost graph, which
+// was built earlier. The nodes of the cost graph must be the rows of the table, numbered from 0.
+// The construction is aborted with an error, when the context is done.
+func BuildAnonGraphFromCostsContext(ctx context.Context, costGraph graph.WeightedUndirected, k int, opts *Options) (graph.Directed, error) {
+	return buildAnonGraph(ctx, denseCosts{costGraph}, costGraph.Nodes().Len(), k, opts)
+}
+
+func buildAnonGraph(ctx context.Context, costs costSource, n, k int, opts *Options) (graph.Directed, error) {
+	g := buildEmptyAnonGraph(n)
+	edges := 0
 	for {
+		if err := checkContext(ctx); err != nil {
+			return nil, err
+		}
 		components := UndirectedConnectedComponents(g)
 		c := pickComponentToExtend(components, k)
 		if c == nil {
 			break
 		}
 		u := pickSourceVertex(g, c)
-		v := pickTargetVertex(g, c, u, costGraph)
+		v, err := costs.pickTargetVertex(g, c, u)
+		if err != nil {
+			return nil, err
+		}
 		g.SetEdge(g.NewEdge(u, v))
+		edges++
+		opts.observer().ForestEdgeAdded(observer.ForestEdgeEvent{
+			From:  u.ID(),
+			To:    v.ID(),
+			Edges: edges,
+			Nodes: n,
+		})
 	}
 	return g, nil
 }
 
+// costSource picks the cheapest vertex to connect a component with.
+type costSource interface {
+	pickTargetVertex(g graph.Directed, component []graph.Node, u graph.Node) (graph.Node, error)
+}
+
+func buildCostSource(ctx context.Context, table *model.Table, opts *Options) (costSource, error) {
+	if m := opts.neighbours(); m > 0 {
+		sparse, err := BuildSparseCostGraph(ctx, table, m, opts)
+		if err != nil {
+			return nil, err
+		}
+		return sparse, nil
+	}
+	costGraph, err := BuildCostGraphContext(ctx, table, opts)
+	if err != nil {
+		return nil, err
+	}
+	return denseCosts{costGraph}, nil
+}
+
+type denseCosts struct {
+	costGraph graph.WeightedUndirected
+}
+
+func (d denseCosts) pickTargetVertex(g graph.Directed, component []graph.Node, u graph.Node) (graph.Node, error) {
+	if v := pickTargetVertex(g, component, u, d.costGraph); v != nil {
+		return v, nil
+	}
+	return nil, errNoTargetVertex(u)
+}
+
+// errNoTargetVertex is returned, when no vertex outside the component of u has a finite cost.
+func errNoTargetVertex(u graph.Node) error {
+	return fmt.Errorf("row %d cannot be grouped with any other row within the generalization limits", u.ID())
+}
+
 func pickSourceVertex(g graph.Directed, component []graph.Node) graph.Node {
 	for _, n := range component {
 		outgoing := g.From(n.ID())
@@ -38,12 +108,14 @@ func pickSourceVertex(g graph.Directed, component []graph.Node) graph.Node {
 	panic("no vertex without outgoing edges in component")
 }
 
+// pickTargetVertex returns the cheapest vertex outside the component, ties are broken by the lowest ID.
+// Returns nil, when there is no vertex with a finite cost.
 func pickTargetVertex(g graph.Directed, component []graph.Node, u graph.Node, costGraph graph.WeightedUndirected) graph.Node {
 	var targetVertex graph.Node
 	minWeight := math.MaxFloat64
-	nodes := costGraph.From(u.ID())
-	for nodes.Next() {
-		n := nodes.Node()
+	nodes := graph.NodesOf(costGraph.From(u.ID()))
+	sortNodes(nodes)
+	for _, n := range nodes {
 		w, _ := costGraph.Weight(u.ID(), n.ID())
 		if !containsNode(component, n) && w < minWeight {
 			minWeight = w
@@ -62,9 +134,9 @@ func pickComponentToExtend(components [][]graph.Node, k int) []graph.Node {
 	return nil
 }
 
-func buildEmptyAnonGraph(t *model.Table) *simple.DirectedGraph {
+func buildEmptyAnonGraph(n int) *simple.DirectedGraph {
 	g := simple.NewDirectedGraph()
-	for i := range t.GetRows() {
+	for i := 0; i < n; i++ {
 		node := simple.Node(i)
 		g.AddNode(node)
 	}
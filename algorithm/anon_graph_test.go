@@ -1,9 +1,14 @@
 package algorithm
 
 import (
+	"context"
+	"errors"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
 	"github.com/gar-r/k-anon/testutil"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
@@ -24,6 +29,54 @@ func TestBuildAnonGraph_2(t *testing.T) {
 	verifyForestProperties(g, t, k)
 }
 
+func TestBuildAnonGraphContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	_, err := BuildAnonGraphContext(ctx, model.GetStudentTable(), 2, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBuildAnonGraphFromCostsContext(t *testing.T) {
+	table := model.GetStudentTable()
+	k := 3
+	costGraph, _ := BuildCostGraph(table)
+	g, err := BuildAnonGraphFromCostsContext(context.Background(), costGraph, k, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyForestProperties(g, t, k)
+	expected, _ := BuildAnonGraph(table, k)
+	for i := range table.GetRows() {
+		for j := range table.GetRows() {
+			testutil.AssertEquals(expected.HasEdgeFromTo(int64(i), int64(j)), g.HasEdgeFromTo(int64(i), int64(j)), t)
+		}
+	}
+}
+
+func TestBuildAnonGraphFromCostsContext_NoFiniteCost(t *testing.T) {
+	costGraph := simple.NewWeightedUndirectedGraph(0, math.MaxFloat64)
+	costGraph.SetWeightedEdge(costGraph.NewWeightedEdge(simple.Node(0), simple.Node(1), 0.5))
+	costGraph.SetWeightedEdge(costGraph.NewWeightedEdge(simple.Node(0), simple.Node(2), math.Inf(1)))
+	costGraph.SetWeightedEdge(costGraph.NewWeightedEdge(simple.Node(1), simple.Node(2), math.Inf(1)))
+	_, err := BuildAnonGraphFromCostsContext(context.Background(), costGraph, 2, nil)
+	testutil.AssertNotNil(err, t)
+}
+
+func TestBuildAnonGraphContext_Observer(t *testing.T) {
+	obs := &recordingObserver{}
+	g, err := BuildAnonGraphContext(context.Background(), model.GetStudentTable(), 3, &Options{Observer: obs})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.AssertEquals(1, len(obs.costGraphs), t)
+	testutil.AssertEquals(28, obs.costGraphs[0].Edges, t)
+	testutil.AssertEquals(UndirectGraph(g).Edges().Len(), len(obs.forestEdges), t)
+	last := obs.forestEdges[len(obs.forestEdges)-1]
+	testutil.AssertEquals(len(obs.forestEdges), last.Edges, t)
+}
+
 // Component 1: 0 --> 1 <-- 2
 // Component 2: 3 --> 4
 // Weights: 1 -[4]-> 3; 1 -[2]->4; all others = 1
@@ -115,3 +168,22 @@ func verifyForestProperties(g graph.Directed, t *testing.T, k int) {
 		}
 	}
 }
+
+type recordingObserver struct {
+	observer.Nop
+	costGraphs  []observer.CostGraphEvent
+	forestEdges []observer.ForestEdgeEvent
+	cuts        []observer.CutEvent
+}
+
+func (r *recordingObserver) CostGraphBuilt(e observer.CostGraphEvent) {
+	r.costGraphs = append(r.costGraphs, e)
+}
+
+func (r *recordingObserver) ForestEdgeAdded(e observer.ForestEdgeEvent) {
+	r.forestEdges = append(r.forestEdges, e)
+}
+
+func (r *recordingObserver) ComponentCut(e observer.CutEvent) {
+	r.cuts = append(r.cuts, e)
+}
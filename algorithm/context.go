@@ -0,0 +1,14 @@
+package algorithm
+
+import (
+	"context"
+	"fmt"
+)
+
+// checkContext returns a wrapped context error, when the context is cancelled or its deadline is exceeded.
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("anonymization interrupted: %w", err)
+	}
+	return nil
+}
@@ -2,37 +2,228 @@ package algorithm
 
 import (
 	"fmt"
+	"math"
+	"sync"
 
 	"github.com/gar-r/k-anon/generalization"
 	"github.com/gar-r/k-anon/model"
 	"github.com/gar-r/k-anon/partition"
 )
 
-// CalculateCost returns the generalization cost between two model rows.
+// CostFunction measures the information lost, when the partitions of two rows in an
+// identifier column are generalized into the same partition. The cost of a column is
+// between 0 (no loss) and 1 (the values are fully generalized), and the cost of a pair of
+// rows is the sum of the column costs weighted with the column weights.
+// Cost is called concurrently by the goroutines building the cost graph (see Options.Workers),
+// so implementations must be safe for concurrent use.
+type CostFunction interface {
+	Cost(p1, p2 partition.Partition, col *model.Column) (float64, error)
+}
+
+// CostFunc is a user-defined CostFunction. Like any CostFunction, it is called concurrently,
+// so it must guard its internal state, such as a cache, against concurrent access.
+type CostFunc func(p1, p2 partition.Partition, col *model.Column) (float64, error)
+
+// Cost calls the function.
+func (f CostFunc) Cost(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+	return f(p1, p2, col)
+}
+
+// CalculateCost returns the generalization cost between two model rows, using the LevelCost.
 func CalculateCost(r1, r2 *model.Row, schema *model.Schema) (float64, error) {
-	var cost float64
+	return CalculateCostWith(r1, r2, schema, LevelCost{})
+}
+
+// CalculateCostWith returns the generalization cost between two model rows, using the given cost function.
+// The cost is infinite, when the rows cannot be generalized into the same partitions within the
+// generalization limits of the columns.
+func CalculateCostWith(r1, r2 *model.Row, schema *model.Schema, cost CostFunction) (float64, error) {
+	var total float64
 	for j, col := range schema.Columns {
 		if col.IsIdentifier() {
-			d1 := r1.Data[j]
-			d2 := r2.Data[j]
-			fraction, err := calculateCostFraction(d1, d2, col.GetGeneralizer())
+			if !WithinLimit(r1.Data[j], r2.Data[j], col) {
+				return math.Inf(1), nil
+			}
+			fraction, err := cost.Cost(r1.Data[j], r2.Data[j], col)
 			if err != nil {
 				return 0, err
 			}
-			cost += fraction * col.GetWeight()
+			total += fraction * col.GetWeight()
 		}
 	}
-	return cost, nil
+	return total, nil
+}
+
+// WithinLimit returns true, when the partitions can be generalized into the same partition
+// within the generalization limit of the column.
+func WithinLimit(p1, p2 partition.Partition, col *model.Column) bool {
+	if !col.IsLimited() {
+		return true
+	}
+	g := col.GetGeneralizer()
+	g1 := g.Generalize(p1, col.GetMaxLevel())
+	return g1 != nil && g1.Equals(g.Generalize(p2, col.GetMaxLevel()))
+}
+
+// LevelCost is the generalization level, at which the partitions become equal,
+// divided by the highest level of the generalizer of the column.
+type LevelCost struct{}
+
+// Cost returns the normalized generalization level of the common partition.
+func (LevelCost) Cost(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+	return calculateCostFraction(p1, p2, col.GetGeneralizer())
 }
 
 func calculateCostFraction(p1, p2 partition.Partition, g generalization.Generalizer) (float64, error) {
+	level, _, err := commonPartition(p1, p2, g)
+	if err != nil {
+		return 0, err
+	}
+	return float64(level) / float64(g.Levels()-1), nil
+}
+
+// commonPartition returns the lowest level, at which the partitions are generalized into
+// the same partition, along with the partition.
+func commonPartition(p1, p2 partition.Partition, g generalization.Generalizer) (int, partition.Partition, error) {
 	maxLevels := g.Levels()
 	for level := 0; level < maxLevels; level++ {
 		g1 := g.Generalize(p1, level)
 		g2 := g.Generalize(p2, level)
 		if g1 != nil && g1.Equals(g2) {
-			return float64(level) / float64(maxLevels-1), nil
+			return level, g1, nil
 		}
 	}
-	return 0, fmt.Errorf(fmt.Sprintf("data cannot be generalized into same partition: %v, %v", p1, p2))
+	return 0, nil, fmt.Errorf(fmt.Sprintf("data cannot be generalized into same partition: %v, %v", p1, p2))
+}
+
+// NCPCost is the normalized certainty penalty of the common partition: the width of a
+// range divided by the width of the domain, and for sets (hierarchy nodes) the number of
+// items divided by the number of items in the domain, or zero for a single item.
+// Unlike the LevelCost, merging values into a narrow range costs less than merging them into
+// a wide one at the same level. The most general partition of a column always costs 1, and
+// other partitions, such as prefixes, fall back to the LevelCost.
+type NCPCost struct{}
+
+// Cost returns the normalized certainty penalty of the common partition.
+func (NCPCost) Cost(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+	g := col.GetGeneralizer()
+	level, common, err := commonPartition(p1, p2, g)
+	if err != nil {
+		return 0, err
+	}
+	top := g.Generalize(p1, g.Levels()-1)
+	if top == nil || common.Equals(top) {
+		return 1, nil
+	}
+	switch c := common.(type) {
+	case partition.Range:
+		if r, ok := top.(partition.Range); ok && r.Max() > r.Min() {
+			return (c.Max() - c.Min()) / (r.Max() - r.Min()), nil
+		}
+	case *partition.Set:
+		if s, ok := top.(*partition.Set); ok && len(s.Items) > 0 {
+			if len(c.Items) == 1 {
+				return 0, nil
+			}
+			return float64(len(c.Items)) / float64(len(s.Items)), nil
+		}
+	}
+	return float64(level) / float64(g.Levels()-1), nil
+}
+
+// EntropyCost is the non-uniform entropy of the common partition, based on the values in
+// the table: the average of -log P(p | common) for the two partitions, where P(p | common)
+// is the fraction of the rows in the common partition, which are also in p. It is divided by
+// the log of the number of rows, so generalizing the rarest value to the most general partition
+// costs 1. Merging rare values into a frequent one costs less than merging frequent values.
+// Create it with NewEntropyCost from the table being anonymized.
+type EntropyCost struct {
+	rows   int
+	counts map[string]map[string]int // number of rows with each partition, by column name
+	values map[string][]partition.Partition
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// NewEntropyCost creates an entropy cost function from the partitions of the table.
+func NewEntropyCost(t *model.Table) *EntropyCost {
+	e := &EntropyCost{
+		rows:   len(t.GetRows()),
+		counts: make(map[string]map[string]int),
+		values: make(map[string][]partition.Partition),
+		cache:  make(map[string]int),
+	}
+	for colIdx, col := range t.GetSchema().Columns {
+		if !col.IsIdentifier() {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, row := range t.GetRows() {
+			p := row.Data[colIdx]
+			if counts[p.String()] == 0 {
+				e.values[col.GetName()] = append(e.values[col.GetName()], p)
+			}
+			counts[p.String()]++
+		}
+		e.counts[col.GetName()] = counts
+	}
+	return e
+}
+
+// Cost returns the normalized non-uniform entropy of the common partition.
+func (e *EntropyCost) Cost(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+	g := col.GetGeneralizer()
+	_, common, err := commonPartition(p1, p2, g)
+	if err != nil {
+		return 0, err
+	}
+	if e.rows < 2 {
+		return 0, nil
+	}
+	n := e.rows
+	if top := g.Generalize(p1, g.Levels()-1); top == nil || !common.Equals(top) {
+		n = e.covered(common, col.GetName())
+	}
+	loss := func(p partition.Partition) float64 {
+		c := max(1, e.covered(p, col.GetName()))
+		return math.Log(float64(max(n, c)) / float64(c))
+	}
+	return (loss(p1) + loss(p2)) / 2 / math.Log(float64(e.rows)), nil
+}
+
+// covered returns the number of rows of the table, which are in the partition.
+func (e *EntropyCost) covered(p partition.Partition, column string) int {
+	key := column + "\x00" + p.String()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n, ok := e.cache[key]; ok {
+		return n
+	}
+	n := 0
+	for _, v := range e.values[column] {
+		if p.Equals(v) || p.ContainsPartition(v) {
+			n += e.counts[column][v.String()]
+		}
+	}
+	e.cache[key] = n
+	return n
+}
+
+// ColumnCost uses a different cost function for each column, selected by the column name.
+// The other columns use the Default cost function, or the LevelCost if Default is nil.
+type ColumnCost struct {
+	Columns map[string]CostFunction
+	Default CostFunction
+}
+
+// Cost returns the cost of the partitions with the cost function of the column.
+func (c *ColumnCost) Cost(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+	if f, ok := c.Columns[col.GetName()]; ok {
+		return f.Cost(p1, p2, col)
+	}
+	if c.Default != nil {
+		return c.Default.Cost(p1, p2, col)
+	}
+	return LevelCost{}.Cost(p1, p2, col)
 }
@@ -1,23 +1,76 @@
 package algorithm
 
 import (
+	"context"
 	"math"
+	"time"
 
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
 )
 
 // BuildCostGraph creates a weighted cost-graph from the table.
 func BuildCostGraph(t *model.Table) (graph.WeightedUndirected, error) {
+	return BuildCostGraphContext(context.Background(), t, nil)
+}
+
+// BuildCostGraphContext creates a weighted cost-graph from the table.
+// The costs are calculated in parallel, see Options.Workers.
+// The construction is aborted with an error, when the context is done.
+func BuildCostGraphContext(ctx context.Context, t *model.Table, opts *Options) (graph.WeightedUndirected, error) {
+	start := time.Now()
 	g := buildEmptyCostGraph(t)
-	err := addCosts(g, t)
+	err := addCosts(ctx, g, t, opts.workers(), opts.cost())
 	if err != nil {
 		return nil, err
 	}
+	opts.observer().CostGraphBuilt(observer.CostGraphEvent{
+		Nodes:    g.Nodes().Len(),
+		Edges:    g.Edges().Len(),
+		Duration: time.Since(start),
+	})
 	return g, nil
 }
 
+// ExtendCostGraphContext adds the rows of the table, which are not in the cost graph yet, to the
+// cost graph, with the costs between them and all other rows. The nodes of the cost graph are
+// the indices of the rows, so rows appended to the table can be added by the next call.
+// The costs are calculated in parallel, see Options.Workers. The graph is only changed,
+// when all costs are calculated; the construction is aborted with an error, when the context is done.
+func ExtendCostGraphContext(ctx context.Context, g *simple.WeightedUndirectedGraph, t *model.Table, opts *Options) error {
+	start := time.Now()
+	rows := t.GetRows()
+	from := g.Nodes().Len()
+	if from >= len(rows) {
+		return nil
+	}
+	var added []rowCosts
+	err := forEachRow(ctx, len(rows)-from, opts.workers(), func(i int) (rowCosts, error) {
+		return calculatePrecedingCosts(rows, from+i, t.GetSchema(), opts.cost())
+	}, func(r rowCosts) {
+		added = append(added, r)
+	})
+	if err != nil {
+		return err
+	}
+	for i := from; i < len(rows); i++ {
+		g.AddNode(simple.Node(i))
+	}
+	for _, r := range added {
+		for j, cost := range r.costs {
+			g.SetWeightedEdge(g.NewWeightedEdge(g.Node(int64(r.row)), g.Node(int64(j)), cost))
+		}
+	}
+	opts.observer().CostGraphBuilt(observer.CostGraphEvent{
+		Nodes:    g.Nodes().Len(),
+		Edges:    g.Edges().Len(),
+		Duration: time.Since(start),
+	})
+	return nil
+}
+
 func buildEmptyCostGraph(t *model.Table) *simple.WeightedUndirectedGraph {
 	g := simple.NewWeightedUndirectedGraph(0, math.MaxFloat64)
 	for i := range t.GetRows() {
@@ -27,21 +80,50 @@ func buildEmptyCostGraph(t *model.Table) *simple.WeightedUndirectedGraph {
 	return g
 }
 
-func addCosts(g *simple.WeightedUndirectedGraph, t *model.Table) error {
-	nodes := len(t.GetRows())
-	for i := 0; i < nodes; i++ {
-		for j := 0; j < nodes; j++ {
-			if i != j {
-				v1 := t.GetRows()[i]
-				v2 := t.GetRows()[j]
-				cost, err := CalculateCost(v1, v2, t.GetSchema())
-				if err != nil {
-					return err
-				}
-				edge := g.NewWeightedEdge(g.Node(int64(i)), g.Node(int64(j)), cost)
-				g.SetWeightedEdge(edge)
-			}
+// addCosts calculates the cost of each unordered pair of rows once, and sets it as
+// the weight of the edge between them. Rows are distributed between the workers,
+// while the edges are added to the graph from the calling goroutine only.
+func addCosts(ctx context.Context, g *simple.WeightedUndirectedGraph, t *model.Table, workers int, cost CostFunction) error {
+	rows := t.GetRows()
+	return forEachRow(ctx, len(rows), workers, func(i int) (rowCosts, error) {
+		return calculateRowCosts(rows, i, t.GetSchema(), cost)
+	}, func(r rowCosts) {
+		for offset, cost := range r.costs {
+			j := r.row + offset + 1
+			edge := g.NewWeightedEdge(g.Node(int64(r.row)), g.Node(int64(j)), cost)
+			g.SetWeightedEdge(edge)
 		}
+	})
+}
+
+// rowCosts holds the costs between a row and each subsequent row of the table, or each
+// preceding row, when calculated by calculatePrecedingCosts.
+type rowCosts struct {
+	row   int
+	costs []float64
+}
+
+func calculateRowCosts(rows []*model.Row, i int, schema *model.Schema, cost CostFunction) (rowCosts, error) {
+	costs := make([]float64, 0, len(rows)-i-1)
+	for j := i + 1; j < len(rows); j++ {
+		c, err := CalculateCostWith(rows[i], rows[j], schema, cost)
+		if err != nil {
+			return rowCosts{}, err
+		}
+		costs = append(costs, c)
 	}
-	return nil
+	return rowCosts{row: i, costs: costs}, nil
+}
+
+// calculatePrecedingCosts calculates the costs between a row and each preceding row of the table.
+func calculatePrecedingCosts(rows []*model.Row, i int, schema *model.Schema, cost CostFunction) (rowCosts, error) {
+	costs := make([]float64, 0, i)
+	for j := 0; j < i; j++ {
+		c, err := CalculateCostWith(rows[i], rows[j], schema, cost)
+		if err != nil {
+			return rowCosts{}, err
+		}
+		costs = append(costs, c)
+	}
+	return rowCosts{row: i, costs: costs}, nil
 }
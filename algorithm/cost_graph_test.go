@@ -1,11 +1,18 @@
 package algorithm
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
 
 	"github.com/gar-r/k-anon/generalization"
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
 	"github.com/gar-r/k-anon/testutil"
+	"gonum.org/v1/gonum/graph/simple"
 )
 
 func TestBuildCostGraph(t *testing.T) {
@@ -72,4 +79,135 @@ func TestBuildCostGraph(t *testing.T) {
 		}
 	})
 
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := BuildCostGraphContext(ctx, model.GetIntTable1(), nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+
+}
+
+func TestBuildCostGraphContext_Workers(t *testing.T) {
+	table := model.GetStudentTable()
+	expected, _ := BuildCostGraphContext(context.Background(), table, &Options{Workers: 1})
+	for _, workers := range []int{0, 2, 3, 16} {
+		t.Run(fmt.Sprintf("workers/%d", workers), func(t *testing.T) {
+			g, err := BuildCostGraphContext(context.Background(), table, &Options{Workers: workers})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := range table.GetRows() {
+				for j := i + 1; j < len(table.GetRows()); j++ {
+					w, _ := expected.Weight(int64(i), int64(j))
+					testutil.AssertEdgeCost(t, g, i, j, w)
+					testutil.AssertEdgeCost(t, g, j, i, w)
+				}
+			}
+		})
+	}
+
+	t.Run("error with multiple workers", func(t *testing.T) {
+		table := model.NewTable(getSchema(1))
+		for i := 1; i < 10; i++ {
+			table.AddRow(i)
+		}
+		table.AddRow(100)
+		_, err := BuildCostGraphContext(context.Background(), table, &Options{Workers: 4})
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+}
+
+func TestExtendCostGraphContext(t *testing.T) {
+	table := model.GetStudentTable()
+	expected, _ := BuildCostGraph(table)
+	g := simple.NewWeightedUndirectedGraph(0, math.MaxFloat64)
+	partial := model.NewTable(table.GetSchema())
+	partial.AppendRows(table.GetRows()[:5]...)
+	for _, t2 := range []*model.Table{partial, table, table} {
+		if err := ExtendCostGraphContext(context.Background(), g, t2, &Options{Workers: 3}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	testutil.AssertEquals(len(table.GetRows()), g.Nodes().Len(), t)
+	for i := range table.GetRows() {
+		for j := i + 1; j < len(table.GetRows()); j++ {
+			w, _ := expected.Weight(int64(i), int64(j))
+			testutil.AssertEdgeCost(t, g, i, j, w)
+		}
+	}
+
+	t.Run("graph is unchanged on error", func(t *testing.T) {
+		table := model.NewTable(getSchema(1))
+		table.AddRow(1)
+		table.AddRow(2)
+		g := simple.NewWeightedUndirectedGraph(0, math.MaxFloat64)
+		if err := ExtendCostGraphContext(context.Background(), g, table, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		table.AddRow(100)
+		if err := ExtendCostGraphContext(context.Background(), g, table, nil); err == nil {
+			t.Errorf("expected error, got nil")
+		}
+		testutil.AssertEquals(2, g.Nodes().Len(), t)
+	})
+}
+
+// TestBuildCostGraphContext_ConcurrentCostFunc is meant to be run with -race, as Cost is
+// called concurrently by the workers.
+func TestBuildCostGraphContext_ConcurrentCostFunc(t *testing.T) {
+	table := model.GetStudentTable()
+	var mu sync.Mutex
+	cache := make(map[string]float64)
+	cached := CostFunc(func(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+		key := col.GetName() + "\x00" + p1.String() + "\x00" + p2.String()
+		mu.Lock()
+		defer mu.Unlock()
+		if c, ok := cache[key]; ok {
+			return c, nil
+		}
+		c, err := LevelCost{}.Cost(p1, p2, col)
+		cache[key] = c
+		return c, err
+	})
+	expected, _ := BuildCostGraphContext(context.Background(), table, nil)
+	g, err := BuildCostGraphContext(context.Background(), table, &Options{Workers: 8, Cost: cached})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range table.GetRows() {
+		for j := i + 1; j < len(table.GetRows()); j++ {
+			w, _ := expected.Weight(int64(i), int64(j))
+			testutil.AssertEdgeCost(t, g, i, j, w)
+		}
+	}
+}
+
+func TestBuildCostGraphContext_Cost(t *testing.T) {
+	table := model.GetIntTable1()
+	ncp := &Options{Cost: NCPCost{}}
+
+	t.Run("dense", func(t *testing.T) {
+		g, err := BuildCostGraphContext(context.Background(), table, ncp)
+		testutil.AssertNil(err, t)
+		for i := range table.GetRows() {
+			for j := i + 1; j < len(table.GetRows()); j++ {
+				expected, _ := CalculateCostWith(table.GetRows()[i], table.GetRows()[j], table.GetSchema(), NCPCost{})
+				testutil.AssertEdgeCost(t, g, i, j, expected)
+			}
+		}
+	})
+
+	t.Run("sparse", func(t *testing.T) {
+		g, err := BuildSparseCostGraph(context.Background(), table, 3, ncp)
+		testutil.AssertNil(err, t)
+		v, cost, err := g.Nearest(0, func(int64) bool { return false })
+		testutil.AssertNil(err, t)
+		expected, _ := CalculateCostWith(table.GetRows()[0], table.GetRows()[v], table.GetSchema(), NCPCost{})
+		testutil.AssertEquals(expected, cost, t)
+	})
 }
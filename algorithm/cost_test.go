@@ -2,10 +2,12 @@ package algorithm
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/gar-r/k-anon/generalization"
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
 	"github.com/gar-r/k-anon/testutil"
 )
 
@@ -90,6 +92,163 @@ func TestCalculateCost(t *testing.T) {
 
 }
 
+func TestNCPCost(t *testing.T) {
+
+	t.Run("ranges", func(t *testing.T) {
+		tests := []struct {
+			item1, item2 int
+			expectedCost float64
+		}{
+			{8, 8, 0},
+			{8, 9, 0.125},
+			{3, 4, 0.125},
+			{1, 4, 0.375},
+			{1, 5, 1},
+		}
+		for i, test := range tests {
+			t.Run(fmt.Sprintf("test #%d", i), func(t *testing.T) {
+				schema := getSchema(1)
+				table := model.NewTable(schema)
+				table.AddRow(test.item1)
+				table.AddRow(test.item2)
+				cost, err := CalculateCostWith(table.GetRows()[0], table.GetRows()[1], schema, NCPCost{})
+				testutil.AssertNil(err, t)
+				testutil.AssertEquals(test.expectedCost, cost, t)
+			})
+		}
+	})
+
+	t.Run("hierarchy", func(t *testing.T) {
+		col := model.NewColumn("Grade", generalization.ExampleGradeGeneralizer())
+		g := col.GetGeneralizer()
+		tests := []struct {
+			item1, item2 string
+			expectedCost float64
+		}{
+			{"A", "A", 0},
+			{"A+", "A", 1.0 / 3},
+			{"A+", "B", 1},
+		}
+		for _, test := range tests {
+			cost, err := NCPCost{}.Cost(g.InitItem(test.item1), g.InitItem(test.item2), col)
+			testutil.AssertNil(err, t)
+			testutil.AssertEquals(test.expectedCost, cost, t)
+		}
+	})
+
+	t.Run("same level, different width", func(t *testing.T) {
+		col := model.NewColumn("Col1", generalization.ExampleIntGeneralizer())
+		g := col.GetGeneralizer()
+		narrow, _ := NCPCost{}.Cost(g.InitItem(8), g.InitItem(9), col)
+		wide, _ := NCPCost{}.Cost(g.InitItem(1), g.InitItem(2), col)
+		level1, _ := LevelCost{}.Cost(g.InitItem(8), g.InitItem(9), col)
+		level2, _ := LevelCost{}.Cost(g.InitItem(1), g.InitItem(2), col)
+		testutil.AssertEquals(0.125, narrow, t)
+		testutil.AssertEquals(0.125, wide, t)
+		testutil.AssertEquals(0.25, level1, t)
+		testutil.AssertEquals(0.5, level2, t)
+	})
+
+	t.Run("prefix falls back to levels", func(t *testing.T) {
+		col := model.NewColumn("Col1", &generalization.PrefixGeneralizer{MaxWords: 4})
+		g := col.GetGeneralizer()
+		cost, err := NCPCost{}.Cost(g.InitItem("cats are nice"), g.InitItem("cats are cute"), col)
+		testutil.AssertNil(err, t)
+		expected, _ := LevelCost{}.Cost(g.InitItem("cats are nice"), g.InitItem("cats are cute"), col)
+		testutil.AssertEquals(expected, cost, t)
+	})
+}
+
+func TestEntropyCost(t *testing.T) {
+	schema := getSchema(1)
+	table := model.NewTable(schema)
+	for _, item := range []int{1, 1, 1, 1, 2, 5} {
+		table.AddRow(item)
+	}
+	cost := NewEntropyCost(table)
+	rows := table.GetRows()
+
+	t.Run("same value", func(t *testing.T) {
+		c, err := CalculateCostWith(rows[0], rows[1], schema, cost)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(0.0, c, t)
+	})
+
+	t.Run("rare and frequent value", func(t *testing.T) {
+		c, err := CalculateCostWith(rows[0], rows[4], schema, cost)
+		testutil.AssertNil(err, t)
+		expected := (math.Log(5.0/4) + math.Log(5.0)) / 2 / math.Log(6)
+		if math.Abs(expected-c) > 1e-9 {
+			t.Errorf("expected %v, got %v", expected, c)
+		}
+	})
+
+	t.Run("fully generalized", func(t *testing.T) {
+		c, err := CalculateCostWith(rows[4], rows[5], schema, cost)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(1.0, c, t)
+	})
+
+	t.Run("cannot generalize into same partition", func(t *testing.T) {
+		other := model.NewTable(schema)
+		other.AddRow(5)
+		other.AddRow(100)
+		_, err := CalculateCostWith(other.GetRows()[0], other.GetRows()[1], schema, cost)
+		testutil.AssertNotNil(err, t)
+	})
+}
+
+func TestColumnCost(t *testing.T) {
+	schema := getSchema(2)
+	table := model.NewTable(schema)
+	table.AddRow(1, 1)
+	table.AddRow(2, 2)
+	fixed := CostFunc(func(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+		return 0.1, nil
+	})
+
+	t.Run("level cost by default", func(t *testing.T) {
+		cost := &ColumnCost{Columns: map[string]CostFunction{"Col1": fixed}}
+		c, err := CalculateCostWith(table.GetRows()[0], table.GetRows()[1], schema, cost)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(0.6, c, t)
+	})
+
+	t.Run("default cost function", func(t *testing.T) {
+		cost := &ColumnCost{Columns: map[string]CostFunction{"Col2": NCPCost{}}, Default: fixed}
+		c, err := CalculateCostWith(table.GetRows()[0], table.GetRows()[1], schema, cost)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(0.225, c, t)
+	})
+}
+
+func TestCalculateCostWith_Limits(t *testing.T) {
+	schema := &model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Col1", generalization.ExampleIntGeneralizer()).WithMaxLevel(2),
+		},
+	}
+	table := model.NewTable(schema)
+	table.AddRow(3)
+	table.AddRow(4)
+	table.AddRow(5)
+	rows := table.GetRows()
+
+	t.Run("within the limit", func(t *testing.T) {
+		cost, err := CalculateCostWith(rows[0], rows[1], schema, NCPCost{})
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(0.125, cost, t)
+		testutil.AssertEquals(true, WithinLimit(rows[0].Data[0], rows[1].Data[0], schema.Columns[0]), t)
+	})
+
+	t.Run("beyond the limit", func(t *testing.T) {
+		cost, err := CalculateCost(rows[1], rows[2], schema)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(math.Inf(1), cost, t)
+		testutil.AssertEquals(false, WithinLimit(rows[1].Data[0], rows[2].Data[0], schema.Columns[0]), t)
+	})
+}
+
 func getSchema(cols int) *model.Schema {
 	g := generalization.ExampleIntGeneralizer()
 	schema := &model.Schema{}
@@ -1,11 +1,13 @@
 package algorithm
 
 import (
+	"context"
 	"math"
+	"math/rand"
 
+	"github.com/gar-r/k-anon/observer"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
-	"gonum.org/v1/gonum/graph/topo"
 )
 
 // Decomposer is responsible for partitioning the cost-graph.
@@ -13,31 +15,49 @@ type Decomposer struct {
 	g           *simple.UndirectedGraph
 	k           int
 	originalLen int
+	observer    observer.Observer
+	rnd         *rand.Rand
 }
 
 // NewDecomposer creates a Decomposer instance from the given graph and K value.
 func NewDecomposer(g *simple.UndirectedGraph, k int) *Decomposer {
+	return NewDecomposerWithOptions(g, k, nil)
+}
+
+// NewDecomposerWithOptions creates a Decomposer instance from the given graph, K value and options.
+// Use Options.Rand to make the decomposition reproducible.
+func NewDecomposerWithOptions(g *simple.UndirectedGraph, k int, opts *Options) *Decomposer {
 	size := 0
 	if g.Nodes() != nil && g.Nodes().Len() > 0 {
 		size = g.Nodes().Len()
 	}
-	return &Decomposer{g: g, k: k, originalLen: size}
+	return &Decomposer{g: g, k: k, originalLen: size, observer: opts.observer(), rnd: opts.rand()}
 }
 
 // Decompose performs the partitioning.
 func (d *Decomposer) Decompose() {
+	_ = d.DecomposeContext(context.Background())
+}
+
+// DecomposeContext performs the partitioning.
+// The partitioning is aborted with an error, when the context is done.
+func (d *Decomposer) DecomposeContext(ctx context.Context) error {
 	threshold := d.getThreshold()
 	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
 		c := d.pickComponent(threshold)
 		if c == nil {
 			break
 		}
 		d.partitionComponent(c)
 	}
+	return nil
 }
 
 func (d *Decomposer) pickComponent(threshold int) []graph.Node {
-	components := topo.ConnectedComponents(d.g)
+	components := ConnectedComponents(d.g)
 	for _, c := range components {
 		if d.calculateSize(c) > threshold {
 			return c
@@ -49,15 +69,33 @@ func (d *Decomposer) pickComponent(threshold int) []graph.Node {
 func (d *Decomposer) partitionComponent(component []graph.Node) {
 	u, v, t := d.getSplitParams(component)
 	s := d.calculateSize(component)
+	var cut observer.CutType
 	if t >= d.k && s-t >= d.k {
+		cut = observer.CutA
 		d.performCutTypeA(u, v)
 	} else if s-t == d.k-1 {
+		if d.isSteinerVertex(v) {
+			d.contractSteinerVertex(v)
+			return
+		}
+		cut = observer.CutB
 		d.performCutTypeB(u, v)
 	} else if t == d.k-1 {
+		if d.isSteinerVertex(u) {
+			d.contractSteinerVertex(u)
+			return
+		}
+		cut = observer.CutC
 		d.performCutTypeC(u, v)
 	} else {
+		if d.isSteinerVertex(u) {
+			d.contractSteinerVertex(u)
+			return
+		}
+		cut = observer.CutD
 		d.performCutTypeD(u, v, component)
 	}
+	d.observer.ComponentCut(observer.CutEvent{Type: cut, Size: s})
 }
 
 func (d *Decomposer) performCutTypeA(u graph.Node, v graph.Node) {
@@ -87,6 +125,34 @@ func (d *Decomposer) performCutTypeD(u graph.Node, v graph.Node, component []gra
 	}
 }
 
+// Cuts of type B, C and D keep one of the cut vertices with the k-1 vertices on its side,
+// so they only produce components of size k, when that vertex is an original one.
+// When the vertex is a Steiner's vertex, it is merged into one of its neighbours instead,
+// and the component is split in a later iteration. Each merge removes a Steiner's vertex
+// from the component, so this always terminates. The Steiner's vertex is left isolated,
+// because removing it would free its ID for reuse in a random order.
+func (d *Decomposer) contractSteinerVertex(sv graph.Node) {
+	neighbours := graph.NodesOf(d.g.From(sv.ID()))
+	sortNodes(neighbours)
+	target := neighbours[0]
+	for _, n := range neighbours {
+		if !d.isSteinerVertex(n) {
+			target = n
+			break
+		}
+	}
+	for _, n := range neighbours {
+		d.g.RemoveEdge(sv.ID(), n.ID())
+		if n.ID() != target.ID() {
+			d.g.SetEdge(d.g.NewEdge(target, n))
+		}
+	}
+}
+
+func (d *Decomposer) isSteinerVertex(n graph.Node) bool {
+	return n.ID() >= int64(d.originalLen)
+}
+
 func (d *Decomposer) getPartitions(u graph.Node, component []graph.Node) ([]graph.Node, []graph.Node) {
 	var comp1, comp2 []graph.Node
 	subTrees := d.getSubTrees(component, u)
@@ -103,9 +169,9 @@ func (d *Decomposer) getPartitions(u graph.Node, component []graph.Node) ([]grap
 func (d *Decomposer) cutSubTrees(u graph.Node, condition func(subRoot graph.Node) bool) {
 	sv := d.g.NewNode()
 	d.g.AddNode(sv) // insert Steiner's vertex for dangling subtrees
-	edges := d.g.From(u.ID())
-	for edges.Next() {
-		n := edges.Node()
+	neighbours := graph.NodesOf(d.g.From(u.ID()))
+	sortNodes(neighbours)
+	for _, n := range neighbours {
 		if condition(n) {
 			d.g.RemoveEdge(u.ID(), n.ID())
 			d.g.SetEdge(d.g.NewEdge(sv, n))
@@ -114,7 +180,7 @@ func (d *Decomposer) cutSubTrees(u graph.Node, condition func(subRoot graph.Node
 }
 
 func (d *Decomposer) getSplitParams(component []graph.Node) (graph.Node, graph.Node, int) {
-	u := pickRandomVertex(component)
+	u := pickRandomVertex(component, d.rnd)
 	s := d.calculateSize(component)
 	for {
 		largest := d.getLargestComponent(d.getSubTrees(component, u))
@@ -145,7 +211,7 @@ func (d *Decomposer) getThreshold() int {
 }
 
 func (d *Decomposer) getLargestComponent(components [][]graph.Node) []graph.Node {
-	max := math.MinInt64
+	max := math.MinInt
 	var result []graph.Node
 	for _, c := range components {
 		size := d.calculateSize(c)
@@ -168,7 +234,7 @@ func (d *Decomposer) getSubTrees(component []graph.Node, root graph.Node) [][]gr
 		}
 	}
 	gCopy.RemoveNode(root.ID())
-	return topo.ConnectedComponents(gCopy)
+	return ConnectedComponents(gCopy)
 }
 
 // Calculates the component size, skipping Steiner's vertices.
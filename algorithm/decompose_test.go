@@ -1,9 +1,14 @@
 package algorithm
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
 	"github.com/gar-r/k-anon/testutil"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
@@ -78,6 +83,32 @@ func TestDecomposer_Decompose_TerminatesWhenFinished(t *testing.T) {
 	d.Decompose()
 }
 
+func TestDecomposer_DecomposeContext_Cancelled(t *testing.T) {
+	g := GetUndirectedTestGraph1()
+	d := NewDecomposer(g, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := d.DecomposeContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	testutil.AssertEquals(1, len(topo.ConnectedComponents(g)), t)
+}
+
+func TestDecomposer_Decompose_Observer(t *testing.T) {
+	obs := &recordingObserver{}
+	d := NewDecomposerWithOptions(GetUndirectedTestGraph1(), 3, &Options{Observer: obs})
+	d.Decompose()
+	if len(obs.cuts) == 0 {
+		t.Errorf("expected cut events")
+	}
+	for _, cut := range obs.cuts {
+		if cut.Size <= d.getThreshold() {
+			t.Errorf("unexpected cut of component with size %d", cut.Size)
+		}
+	}
+}
+
 func TestDecomposer_Decompose_ComponentSizes(t *testing.T) {
 	g := GetUndirectedTestGraph1()
 	k := 3
@@ -98,6 +129,56 @@ func TestDecomposer_Decompose_ComponentSizes(t *testing.T) {
 	}
 }
 
+func TestDecomposer_Decompose_SteinersVertexCut(t *testing.T) {
+	gen := generalization.NewIntRangeGeneralizer(0, 100)
+	k := 4
+	for seed := int64(100); seed < 120; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		table := model.NewTable(&model.Schema{
+			Columns: []*model.Column{
+				model.NewColumn("a", gen),
+				model.NewColumn("b", gen),
+			},
+		})
+		for i := 0; i < 40; i++ {
+			table.AddRow(rnd.Intn(100), rnd.Intn(100))
+		}
+		g, err := BuildAnonGraph(table, k)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		u := UndirectGraph(g)
+		d := NewDecomposerWithOptions(u, k, &Options{Rand: rand.New(rand.NewSource(seed))})
+		d.Decompose()
+		for _, c := range ConnectedComponents(u) {
+			compLen := 0
+			for _, n := range c {
+				if !d.isSteinerVertex(n) {
+					compLen++
+				}
+			}
+			if compLen > 0 && compLen < k {
+				t.Errorf("seed %d: component size < k: %v", seed, c)
+			}
+		}
+	}
+}
+
+func TestDecomposer_ContractSteinerVertex(t *testing.T) {
+	g := GetUndirectedTestGraph2()
+	d := NewDecomposer(g, 4)
+	d.performCutTypeB(g.Node(0), g.Node(1))
+	d.contractSteinerVertex(g.Node(8))
+	if g.From(8).Len() != 0 {
+		t.Errorf("expected Steiner's vertex to be isolated")
+	}
+	for _, n := range []int64{3, 4} {
+		if !g.HasEdgeBetween(2, n) {
+			t.Errorf("expected edge between 2 and %d", n)
+		}
+	}
+}
+
 //   -- 0 --
 //  |       |
 //  1       3
@@ -3,7 +3,7 @@ package algorithm
 import (
 	"math"
 	"math/rand"
-	"time"
+	"sort"
 
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
@@ -54,14 +54,36 @@ func AddWeightedEdge(g interface {
 }
 
 // UndirectedConnectedComponents gets the connected components by treating the directed graph as undirected.
+// The components are returned in a stable order, see ConnectedComponents.
 func UndirectedConnectedComponents(g graph.Directed) [][]graph.Node {
 	var components [][]graph.Node
 	if !isEmpty(g) {
-		components = topo.ConnectedComponents(graph.Undirect{G: g})
+		components = ConnectedComponents(graph.Undirect{G: g})
 	}
 	return components
 }
 
+// ConnectedComponents returns the connected components of the undirected graph in a stable order.
+// The nodes of each component are sorted by ID, and the components are sorted by their first node.
+// Gonum iterates the nodes of its graphs in random order, so without the sorting
+// the same input could result in different anonymization results.
+func ConnectedComponents(g graph.Undirected) [][]graph.Node {
+	components := topo.ConnectedComponents(g)
+	for _, c := range components {
+		sortNodes(c)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0].ID() < components[j][0].ID()
+	})
+	return components
+}
+
+func sortNodes(nodes []graph.Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID() < nodes[j].ID()
+	})
+}
+
 // UndirectGraph converts a directed graph to a simple undirected graph implementation.
 func UndirectGraph(g graph.Directed) *simple.UndirectedGraph {
 	undirected := simple.NewUndirectedGraph()
@@ -73,9 +95,8 @@ func isEmpty(g graph.Directed) bool {
 	return g.Nodes() == nil || g.Nodes().Len() < 1
 }
 
-func pickRandomVertex(component []graph.Node) graph.Node {
-	rand.Seed(time.Now().Unix())
-	i := rand.Intn(len(component))
+func pickRandomVertex(component []graph.Node, rnd *rand.Rand) graph.Node {
+	i := rnd.Intn(len(component))
 	return component[i]
 }
 
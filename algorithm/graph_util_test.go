@@ -0,0 +1,32 @@
+package algorithm
+
+import (
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+func TestConnectedComponents(t *testing.T) {
+	g := CreateNodesUndirected(6)
+	AddEdge(g, 5, 1)
+	AddEdge(g, 1, 3)
+	AddEdge(g, 4, 0)
+	expected := [][]int64{{0, 4}, {1, 3, 5}, {2}}
+	for i := 0; i < 10; i++ {
+		actual := ids(ConnectedComponents(g))
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+	}
+}
+
+func ids(components [][]graph.Node) [][]int64 {
+	result := make([][]int64, len(components))
+	for i, c := range components {
+		for _, n := range c {
+			result[i] = append(result[i], n.ID())
+		}
+	}
+	return result
+}
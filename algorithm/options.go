@@ -0,0 +1,68 @@
+package algorithm
+
+import (
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/gar-r/k-anon/observer"
+)
+
+// Options holds the optional settings of the anonymization graph algorithms.
+// A nil *Options is valid, and stands for the default settings.
+type Options struct {
+	// Observer receives progress events, when set.
+	Observer observer.Observer
+
+	// Workers is the number of goroutines used to build the cost graph.
+	// Zero or a negative value means runtime.GOMAXPROCS(0).
+	Workers int
+
+	// Neighbours enables the sparse cost graph when positive, keeping only this many
+	// candidate rows for each row (see SparseCostGraph). The default is the dense cost graph.
+	Neighbours int
+
+	// Rand is the source of randomness of the decomposition. Runs with identically
+	// seeded sources produce identical results for the same input and K.
+	// The default is a source seeded with the current time.
+	Rand *rand.Rand
+
+	// Cost measures the generalization cost between rows in the cost graph.
+	// The default is the LevelCost.
+	Cost CostFunction
+}
+
+func (o *Options) observer() observer.Observer {
+	if o == nil || o.Observer == nil {
+		return observer.Nop{}
+	}
+	return o.Observer
+}
+
+func (o *Options) workers() int {
+	if o == nil || o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+func (o *Options) neighbours() int {
+	if o == nil || o.Neighbours < 0 {
+		return 0
+	}
+	return o.Neighbours
+}
+
+func (o *Options) cost() CostFunction {
+	if o == nil || o.Cost == nil {
+		return LevelCost{}
+	}
+	return o.Cost
+}
+
+func (o *Options) rand() *rand.Rand {
+	if o == nil || o.Rand == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return o.Rand
+}
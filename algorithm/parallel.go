@@ -0,0 +1,60 @@
+package algorithm
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachRow calls work for each row index in [0, n) on the given number of goroutines,
+// and passes the results to collect on the calling goroutine. The first error returned
+// by work stops the processing, and is returned as is.
+func forEachRow[T any](ctx context.Context, n, workers int, work func(i int) (T, error), collect func(T)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan int)
+	results := make(chan T)
+	errs := make(chan error, workers)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := work(i)
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := 0; i < n; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for result := range results {
+		collect(result)
+	}
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return checkContext(ctx)
+	}
+}
@@ -0,0 +1,223 @@
+package algorithm
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
+	"github.com/gar-r/k-anon/partition"
+	"gonum.org/v1/gonum/graph"
+)
+
+// SparseCostGraph is a memory efficient alternative of the cost graph for large tables.
+// Instead of all O(n²) weighted edges, it only keeps the m cheapest candidate rows of each row.
+// Candidates are found with sorted neighbourhoods on the range columns (rows which are close
+// to each other when sorted by the column value) and blocking on the other identifier columns
+// (rows sharing the same value). When the candidates of a row run out, the costs to the
+// remaining rows are calculated on demand.
+type SparseCostGraph struct {
+	table      *model.Table
+	m          int
+	cost       CostFunction
+	candidates [][]candidate
+}
+
+type candidate struct {
+	row  int64
+	cost float64
+}
+
+// BuildSparseCostGraph creates a sparse cost graph from the table, keeping m candidates per row.
+// The construction is aborted with an error, when the context is done.
+func BuildSparseCostGraph(ctx context.Context, t *model.Table, m int, opts *Options) (*SparseCostGraph, error) {
+	start := time.Now()
+	rows := t.GetRows()
+	g := &SparseCostGraph{table: t, m: m, cost: opts.cost(), candidates: make([][]candidate, len(rows))}
+	neighbours := findNeighbours(t, m)
+	edges := 0
+	err := forEachRow(ctx, len(rows), opts.workers(), func(i int) (rowCandidates, error) {
+		cs, err := g.rank(int64(i), neighbours[i])
+		return rowCandidates{row: i, candidates: cs}, err
+	}, func(r rowCandidates) {
+		g.candidates[r.row] = r.candidates
+		edges += len(r.candidates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts.observer().CostGraphBuilt(observer.CostGraphEvent{
+		Nodes:    len(rows),
+		Edges:    edges,
+		Duration: time.Since(start),
+	})
+	return g, nil
+}
+
+// Candidates returns the currently known candidate rows of row u, cheapest first.
+func (g *SparseCostGraph) Candidates(u int64) []int64 {
+	var result []int64
+	for _, c := range g.candidates[u] {
+		result = append(result, c.row)
+	}
+	return result
+}
+
+// Nearest returns the cheapest row for u, which is not excluded, along with its cost.
+// When all candidates of u are excluded, the costs to every remaining row are calculated,
+// and the candidates are refilled with the m cheapest ones. Since the component of a row
+// only grows while the anonymization graph is built, excluded rows never need to be considered again.
+// The returned row is -1 when every other row is excluded.
+func (g *SparseCostGraph) Nearest(u int64, excluded func(v int64) bool) (int64, float64, error) {
+	for len(g.candidates[u]) > 0 {
+		c := g.candidates[u][0]
+		if !excluded(c.row) {
+			return c.row, c.cost, nil
+		}
+		g.candidates[u] = g.candidates[u][1:]
+	}
+	var remaining []int64
+	for v := range g.table.GetRows() {
+		if int64(v) != u && !excluded(int64(v)) {
+			remaining = append(remaining, int64(v))
+		}
+	}
+	cs, err := g.rank(u, remaining)
+	if err != nil {
+		return -1, 0, err
+	}
+	g.candidates[u] = cs
+	if len(cs) == 0 {
+		return -1, math.MaxFloat64, nil
+	}
+	return cs[0].row, cs[0].cost, nil
+}
+
+func (g *SparseCostGraph) pickTargetVertex(ag graph.Directed, component []graph.Node, u graph.Node) (graph.Node, error) {
+	ids := make(map[int64]bool, len(component))
+	for _, n := range component {
+		ids[n.ID()] = true
+	}
+	v, cost, err := g.Nearest(u.ID(), func(v int64) bool { return ids[v] })
+	if err != nil {
+		return nil, err
+	}
+	if v < 0 || math.IsInf(cost, 1) {
+		return nil, errNoTargetVertex(u)
+	}
+	return ag.Node(v), nil
+}
+
+// rank calculates the costs between u and the given rows, and returns the m cheapest ones.
+// Ties are broken by row index to keep the result stable.
+func (g *SparseCostGraph) rank(u int64, rows []int64) ([]candidate, error) {
+	all := g.table.GetRows()
+	cs := make([]candidate, 0, len(rows))
+	for _, v := range rows {
+		cost, err := CalculateCostWith(all[u], all[v], g.table.GetSchema(), g.cost)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, candidate{row: v, cost: cost})
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].cost == cs[j].cost {
+			return cs[i].row < cs[j].row
+		}
+		return cs[i].cost < cs[j].cost
+	})
+	if len(cs) > g.m {
+		cs = cs[:g.m]
+	}
+	return cs, nil
+}
+
+type rowCandidates struct {
+	row        int
+	candidates []candidate
+}
+
+// findNeighbours collects the candidate rows for each row. For each range column the rows
+// are sorted by the center of their range, and the m rows before and after a row are its
+// candidates. For the other identifier columns rows with the same value form a block, and
+// up to m rows from the block are candidates.
+func findNeighbours(t *model.Table, m int) [][]int64 {
+	rows := t.GetRows()
+	sets := make([]map[int64]bool, len(rows))
+	for i := range sets {
+		sets[i] = make(map[int64]bool)
+	}
+	add := func(order []int64, window int) {
+		for pos, u := range order {
+			for d := 1; d <= window; d++ {
+				if pos-d >= 0 {
+					sets[u][order[pos-d]] = true
+				}
+				if pos+d < len(order) {
+					sets[u][order[pos+d]] = true
+				}
+			}
+		}
+	}
+	for colIdx, col := range t.GetSchema().Columns {
+		if !col.IsIdentifier() {
+			continue
+		}
+		if isRangeColumn(rows, colIdx) {
+			add(sortByCenter(rows, colIdx), m)
+		} else {
+			for _, block := range blocks(rows, colIdx) {
+				add(block, m/2+1)
+			}
+		}
+	}
+	result := make([][]int64, len(rows))
+	for u, set := range sets {
+		for v := range set {
+			result[u] = append(result[u], v)
+		}
+		sort.Slice(result[u], func(i, j int) bool { return result[u][i] < result[u][j] })
+	}
+	return result
+}
+
+func isRangeColumn(rows []*model.Row, colIdx int) bool {
+	for _, row := range rows {
+		if _, ok := row.Data[colIdx].(partition.Range); !ok {
+			return false
+		}
+	}
+	return len(rows) > 0
+}
+
+func sortByCenter(rows []*model.Row, colIdx int) []int64 {
+	order := make([]int64, len(rows))
+	centers := make([]float64, len(rows))
+	for i, row := range rows {
+		r := row.Data[colIdx].(partition.Range)
+		order[i] = int64(i)
+		centers[i] = (r.Min() + r.Max()) / 2
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return centers[order[i]] < centers[order[j]]
+	})
+	return order
+}
+
+func blocks(rows []*model.Row, colIdx int) [][]int64 {
+	index := make(map[string]int)
+	var result [][]int64
+	for i, row := range rows {
+		key := row.Data[colIdx].String()
+		b, ok := index[key]
+		if !ok {
+			b = len(result)
+			index[key] = b
+			result = append(result, nil)
+		}
+		result[b] = append(result[b], int64(i))
+	}
+	return result
+}
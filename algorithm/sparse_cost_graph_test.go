@@ -0,0 +1,150 @@
+package algorithm
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+	"gonum.org/v1/gonum/graph"
+)
+
+func TestBuildSparseCostGraph(t *testing.T) {
+
+	t.Run("candidates are limited and ordered", func(t *testing.T) {
+		table := model.GetStudentTable()
+		g, err := BuildSparseCostGraph(context.Background(), table, 3, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for u := range table.GetRows() {
+			candidates := g.Candidates(int64(u))
+			if len(candidates) == 0 || len(candidates) > 3 {
+				t.Errorf("unexpected candidate count for row %d: %d", u, len(candidates))
+			}
+			prev := -1.0
+			for _, v := range candidates {
+				if v == int64(u) {
+					t.Errorf("row %d is a candidate of itself", u)
+				}
+				cost, _ := CalculateCost(table.GetRows()[u], table.GetRows()[v], table.GetSchema())
+				if cost < prev {
+					t.Errorf("candidates of row %d are not ordered by cost", u)
+				}
+				prev = cost
+			}
+		}
+	})
+
+	t.Run("matches dense graph with enough candidates", func(t *testing.T) {
+		table := model.GetIntTable1()
+		dense, _ := BuildCostGraph(table)
+		sparse, _ := BuildSparseCostGraph(context.Background(), table, 3, nil)
+		for u := range table.GetRows() {
+			v, cost, err := sparse.Nearest(int64(u), func(v int64) bool { return false })
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			w, _ := dense.Weight(int64(u), v)
+			testutil.AssertEquals(w, cost, t)
+			for x := range table.GetRows() {
+				if x != u {
+					if w, _ := dense.Weight(int64(u), int64(x)); w < cost {
+						t.Errorf("row %d is cheaper than nearest row %d for %d", x, v, u)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		table := model.NewTable(getSchema(1))
+		table.AddRow(1)
+		table.AddRow(100)
+		_, err := BuildSparseCostGraph(context.Background(), table, 2, nil)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+}
+
+func TestSparseCostGraph_Nearest(t *testing.T) {
+	// 0, 1, 2 and 3 are close to each other, 4 is far away
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Col1", generalization.NewIntRangeGeneralizer(0, 127)),
+		},
+	})
+	table.AddRow(1)
+	table.AddRow(2)
+	table.AddRow(3)
+	table.AddRow(4)
+	table.AddRow(120)
+	g, _ := BuildSparseCostGraph(context.Background(), table, 1, nil)
+
+	t.Run("cheapest candidate", func(t *testing.T) {
+		v, _, _ := g.Nearest(0, func(v int64) bool { return false })
+		testutil.AssertEquals(int64(1), v, t)
+	})
+
+	t.Run("candidates run out", func(t *testing.T) {
+		excluded := map[int64]bool{0: true, 1: true}
+		v, _, err := g.Nearest(0, func(v int64) bool { return excluded[v] })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v != 2 && v != 3 {
+			t.Errorf("expected row 2 or 3, got %d", v)
+		}
+	})
+
+	t.Run("everything excluded", func(t *testing.T) {
+		v, _, _ := g.Nearest(4, func(v int64) bool { return true })
+		testutil.AssertEquals(int64(-1), v, t)
+	})
+}
+
+func TestBuildAnonGraphContext_Sparse(t *testing.T) {
+	gen := generalization.NewIntRangeGeneralizer(0, 100)
+	schema := &model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Col1", gen),
+			model.NewColumn("Col2", gen),
+			model.NewColumn("Col3", gen),
+		},
+	}
+	table := model.NewTable(schema)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 60; i++ {
+		table.AddRow(rnd.Intn(100), rnd.Intn(100), rnd.Intn(100))
+	}
+	k := 3
+	dense, _ := BuildAnonGraph(table, k)
+	sparse, err := BuildAnonGraphContext(context.Background(), table, k, &Options{Neighbours: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	verifyForestProperties(sparse, t, k)
+	denseCost := forestCost(dense, table, t)
+	sparseCost := forestCost(sparse, table, t)
+	if sparseCost > 1.25*denseCost {
+		t.Errorf("sparse forest cost %v is not comparable to dense forest cost %v", sparseCost, denseCost)
+	}
+}
+
+func forestCost(g graph.Directed, table *model.Table, t *testing.T) float64 {
+	t.Helper()
+	total := 0.0
+	edges := UndirectGraph(g).Edges()
+	for edges.Next() {
+		e := edges.Edge()
+		cost, err := CalculateCost(table.GetRows()[e.From().ID()], table.GetRows()[e.To().ID()], table.GetSchema())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		total += cost
+	}
+	return total
+}
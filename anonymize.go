@@ -1,10 +1,17 @@
 package kanon
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
 	"github.com/gar-r/k-anon/algorithm"
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
+	"github.com/gar-r/k-anon/partition"
 	"gonum.org/v1/gonum/graph"
-	"gonum.org/v1/gonum/graph/topo"
+	"gonum.org/v1/gonum/graph/simple"
 )
 
 // Anonymizer is a graph based data anonymizer which operates on Table data.
@@ -12,60 +19,188 @@ import (
 // all records are samePartition or suppressed in a way, that given any record
 // there are other K-1 records in the Table that are identical
 // to it along quasi-identifier attributes.
+// Columns with a transformer are masked after the generalization step.
+// The optional Observer receives progress events during the anonymization.
+// Workers sets the number of goroutines building the cost graph (defaults to GOMAXPROCS).
+// Setting Neighbours to a positive value enables the sparse cost graph for large tables,
+// which keeps only the given number of cheapest candidates for each row.
+// Rand is the source of randomness used by the anonymization; runs with identically
+// seeded sources produce identical results for the same Table and K (defaults to a
+// time-seeded source).
+// Cost measures the generalization cost between rows, which decides the groups
+// (defaults to algorithm.LevelCost).
+// When K-anonymity cannot be achieved within the generalization limits of the columns, the
+// anonymizer fails with a ConstraintError, or suppresses the blocking rows if Suppress is set.
+// When the schema declares quasi-identifier sets, each set is K-anonymized with its own K,
+// instead of all identifier columns together.
+// In Incremental mode the anonymizer keeps the cost graph, the anonymization forest and the
+// groups of the previous runs. Rows added to the Table since the last run join an existing
+// group, when they generalize into its partitions, or form new groups among themselves.
+// Published groups are never generalized again. Rows which cannot be grouped with K-1 other
+// rows yet are pending (see Pending) instead of blocking, so Suppress has no effect. The
+// sparse cost graph is not supported in Incremental mode.
+// M, ID and Sensitive configure the m-invariant republication of the Table with Publish,
+// which keeps the history of the releases in the anonymizer.
 type Anonymizer struct {
-	K     int
-	Table *model.Table
+	K           int
+	Table       *model.Table
+	Observer    observer.Observer
+	Workers     int
+	Neighbours  int
+	Rand        *rand.Rand
+	Cost        algorithm.CostFunction
+	Suppress    bool
+	Incremental bool
+	M           int
+	ID          string
+	Sensitive   string
+
+	state       *runState
+	republisher *republisher
+	transformed int   // number of rows already masked by column transformers
+	suppressed  []int // rows suppressed because of the generalization limits
 }
 
 // Anonymize creates a K-anonymized Table from the input Table.
 func (a *Anonymizer) Anonymize() error {
-	g, err := a.computeAnonGraph()
+	return a.AnonymizeContext(context.Background())
+}
+
+// AnonymizeContext creates a K-anonymized Table from the input Table.
+// The anonymization is aborted, when the context is cancelled or its deadline
+// is exceeded. In that case the returned error wraps the context error, and the
+// Table is left unchanged.
+func (a *Anonymizer) AnonymizeContext(ctx context.Context) error {
+	if len(a.Table.GetSchema().QuasiIdentifiers) > 0 {
+		if err := a.anonymizeQuasiIdentifiers(ctx); err != nil {
+			return err
+		}
+		return a.transform()
+	}
+	if a.K < 1 {
+		return fmt.Errorf("invalid value for K: %d", a.K)
+	}
+	if a.Incremental {
+		if a.state == nil || len(a.state.originals) != a.state.published {
+			a.state = newRunState()
+		}
+		if err := a.anonymizeIncremental(ctx); err != nil {
+			return err
+		}
+		return a.transform()
+	}
+	if a.K > len(a.Table.GetRows()) {
+		return fmt.Errorf("K=%d, but the table has %d rows", a.K, len(a.Table.GetRows()))
+	}
+	rows := a.unsuppressed()
+	blocks, small := a.blocks(rows)
+	blocked := flatten(small)
+	if err := a.checkBlocked(blocked); err != nil {
+		return err
+	}
+	state, err := a.anonymizeBlocks(ctx, blocks)
 	if err != nil {
 		return err
 	}
-	components := topo.ConnectedComponents(g)
-	groups := a.getRowGroups(components)
-	a.generalize(groups)
-	return nil
+	a.suppress(blocked)
+	state.published = len(a.Table.GetRows())
+	a.state = state
+	return a.transform()
 }
 
-func (a *Anonymizer) computeAnonGraph() (graph.Undirected, error) {
-	g, err := algorithm.BuildAnonGraph(a.Table, a.K)
+// anonymizeBlocks runs the graph based anonymization on each block of rows of the Table,
+// and returns the resulting groups as row indices, along with the decomposed forest.
+func (a *Anonymizer) anonymizeBlocks(ctx context.Context, blocks [][]int) (*runState, error) {
+	state := newRunState()
+	for _, rows := range blocks {
+		g, err := a.computeAnonGraph(ctx, a.Table.Select(rows))
+		if err != nil {
+			return nil, err
+		}
+		components := algorithm.ConnectedComponents(g)
+		state.groups = append(state.groups, getRowGroups(components, rows)...)
+		state.addForest(rows, g)
+	}
+	a.generalize(state.groups)
+	return state, nil
+}
+
+func (a *Anonymizer) computeAnonGraph(ctx context.Context, table *model.Table) (*simple.UndirectedGraph, error) {
+	opts := a.options()
+	g, err := algorithm.BuildAnonGraphContext(ctx, table, a.K, opts)
 	if err != nil {
 		return nil, err
 	}
 	undirected := algorithm.UndirectGraph(g)
-	d := algorithm.NewDecomposer(undirected, a.K)
-	d.Decompose()
+	d := algorithm.NewDecomposerWithOptions(undirected, a.K, opts)
+	if err := d.DecomposeContext(ctx); err != nil {
+		return nil, err
+	}
 	return undirected, nil
 }
 
-func (a *Anonymizer) getRowGroups(components [][]graph.Node) [][]*model.Row {
-	var groups [][]*model.Row
+func (a *Anonymizer) options() *algorithm.Options {
+	return &algorithm.Options{
+		Observer:   a.Observer,
+		Workers:    a.Workers,
+		Neighbours: a.Neighbours,
+		Rand:       a.Rand,
+		Cost:       a.Cost,
+	}
+}
+
+func (a *Anonymizer) cost() algorithm.CostFunction {
+	if a.Cost == nil {
+		return algorithm.LevelCost{}
+	}
+	return a.Cost
+}
+
+// getRowGroups maps the components of the anonymization graph built over the
+// given rows to groups of row indices.
+func getRowGroups(components [][]graph.Node, rows []int) [][]int {
+	var groups [][]int
 	for _, component := range components {
-		var group []*model.Row
+		var group []int
 		for _, n := range component {
 			id := int(n.ID())
-			if id < len(a.Table.GetRows()) { // skip Steiner's vertices
-				group = append(group, a.Table.GetRows()[id])
+			if id < len(rows) { // skip Steiner's vertices
+				group = append(group, rows[id])
 			}
 		}
-		groups = append(groups, group)
+		if len(group) > 0 { // isolated Steiner's vertices
+			groups = append(groups, group)
+		}
 	}
 	return groups
 }
 
-func (a *Anonymizer) generalize(groups [][]*model.Row) {
+func (a *Anonymizer) generalize(groups [][]int) {
 	for _, group := range groups {
-		a.generalizeRowGroup(group)
+		start := time.Now()
+		a.generalizeRowGroup(a.getRows(group))
+		if a.Observer != nil {
+			a.Observer.GroupGeneralized(observer.GroupEvent{
+				Size:     len(group),
+				Duration: time.Since(start),
+			})
+		}
 	}
 }
 
+func (a *Anonymizer) getRows(indices []int) []*model.Row {
+	rows := make([]*model.Row, len(indices))
+	for i, idx := range indices {
+		rows[i] = a.Table.GetRows()[idx]
+	}
+	return rows
+}
+
 func (a *Anonymizer) generalizeRowGroup(rows []*model.Row) {
 	for colIdx := 0; colIdx < len(a.Table.GetSchema().Columns); colIdx++ {
 		colDef := a.Table.GetSchema().Columns[colIdx]
 		if colDef.IsIdentifier() {
-			for level := 0; level < colDef.GetGeneralizer().Levels(); level++ {
+			for level := 0; level <= colDef.GetMaxLevel(); level++ {
 				for _, row := range rows {
 					p := colDef.GetGeneralizer().Generalize(row.Data[colIdx], level)
 					row.Data[colIdx] = p
@@ -78,14 +213,54 @@ func (a *Anonymizer) generalizeRowGroup(rows []*model.Row) {
 	}
 }
 
+// transform applies the column transformers on rows, which were not transformed
+// by a previous call. Transformations are not idempotent, so each row is only
+// transformed once, even when the anonymizer is called repeatedly. The transformed
+// values are only written to the Table, when all of them succeed, so a failed call
+// leaves the rows to be transformed by the next one.
+func (a *Anonymizer) transform() error {
+	rows := a.Table.GetRows()
+	columns := a.Table.GetSchema().Columns
+	transformed := make([][]partition.Partition, len(columns))
+	for colIdx, colDef := range columns {
+		if !colDef.IsTransformed() {
+			continue
+		}
+		for rowIdx := a.transformed; rowIdx < len(rows); rowIdx++ {
+			p, err := colDef.GetTransformer().Transform(rows[rowIdx].Data[colIdx])
+			if err != nil {
+				return fmt.Errorf("cannot transform row %d in column %s: %w", rowIdx, colDef.GetName(), err)
+			}
+			transformed[colIdx] = append(transformed[colIdx], p)
+		}
+	}
+	for colIdx, values := range transformed {
+		for i, p := range values {
+			rows[a.transformed+i].Data[colIdx] = p
+		}
+	}
+	a.transformed = len(rows)
+	return nil
+}
+
+// samePartition returns true, when the rows have the same partition in the column.
+// Missing partitions of values, which cannot be generalized, are never the same.
 func samePartition(colIdx int, rows []*model.Row) bool {
 	if len(rows) > 1 {
 		first := rows[0]
 		for _, row := range rows {
-			if !first.Data[colIdx].Equals(row.Data[colIdx]) {
+			if first.Data[colIdx] == nil || row.Data[colIdx] == nil || !first.Data[colIdx].Equals(row.Data[colIdx]) {
 				return false
 			}
 		}
 	}
 	return true
 }
+
+func flatten(blocks [][]int) []int {
+	var rows []int
+	for _, block := range blocks {
+		rows = append(rows, block...)
+	}
+	return rows
+}
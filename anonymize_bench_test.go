@@ -44,6 +44,27 @@ func BenchmarkAnonymizerK(b *testing.B) {
 	}
 }
 
+func BenchmarkAnonymizerWorkers(b *testing.B) {
+	gen := generalization.NewIntRangeGeneralizer(rangeMin, rangeMax)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers/%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer() // the table is anonymized in place, so each iteration needs a fresh one
+				table := randomTable(10, 100, gen)
+				b.StartTimer()
+				anon := &Anonymizer{
+					Table:   table,
+					K:       4,
+					Workers: workers,
+				}
+				if err := anon.Anonymize(); err != nil {
+					b.Error("error while anonymizing table", err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkAnonymizerColumnTypes(b *testing.B) {
 	items := make([]interface{}, rangeMax)
 	for i := range items {
@@ -1,10 +1,20 @@
 package kanon
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/generalization"
 	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/transformation"
 )
 
 func TestAnonymizer_Anonymize(t *testing.T) {
@@ -32,6 +42,242 @@ func TestAnonymizer_Anonymize(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("test K-anonymity with sparse cost graph", func(t *testing.T) {
+		tables := []*model.Table{
+			model.GetIntTable1(),
+			model.GetMixedTable2(),
+			model.GetStudentTable(),
+		}
+		for i, table := range tables {
+			t.Run(fmt.Sprintf("Table %d", i), func(t *testing.T) {
+				anon := &Anonymizer{
+					Table:      table,
+					K:          2,
+					Neighbours: 2,
+				}
+				if err := anon.Anonymize(); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				assertKAnonymity(table, 2, t)
+			})
+		}
+	})
+}
+
+func TestAnonymizer_AnonymizeContext(t *testing.T) {
+
+	t.Run("cancelled", func(t *testing.T) {
+		table := model.GetStudentTable()
+		expected := table.String()
+		anon := &Anonymizer{
+			Table: table,
+			K:     2,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := anon.AnonymizeContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+		if expected != table.String() {
+			t.Errorf("table should be left unchanged")
+		}
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		anon := &Anonymizer{
+			Table: model.GetStudentTable(),
+			K:     2,
+		}
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		err := anon.AnonymizeContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("not cancelled", func(t *testing.T) {
+		table := model.GetStudentTable()
+		anon := &Anonymizer{
+			Table: table,
+			K:     2,
+		}
+		if err := anon.AnonymizeContext(context.Background()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		assertKAnonymity(table, 2, t)
+	})
+
+	t.Run("invalid K", func(t *testing.T) {
+		for _, k := range []int{0, len(model.GetStudentTable().GetRows()) + 1} {
+			anon := &Anonymizer{Table: model.GetStudentTable(), K: k}
+			if err := anon.Anonymize(); err == nil {
+				t.Errorf("expected error for K=%d", k)
+			}
+		}
+	})
+}
+
+func TestAnonymizer_Observer(t *testing.T) {
+	table := model.GetStudentTable()
+	p := observer.NewPrometheus()
+	anon := &Anonymizer{
+		Table:    table,
+		K:        2,
+		Observer: p,
+	}
+	if err := anon.Anonymize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sb := &strings.Builder{}
+	_, _ = p.WriteTo(sb)
+	expected := fmt.Sprintf("kanon_rows_generalized_total %d\n", len(table.GetRows()))
+	if !strings.Contains(sb.String(), expected) {
+		t.Errorf("expected output to contain %q, got:\n%s", expected, sb.String())
+	}
+}
+
+func TestAnonymizer_Rand(t *testing.T) {
+	anonymize := func() string {
+		rnd := rand.New(rand.NewSource(113))
+		table := model.NewTable(&model.Schema{
+			Columns: makeCols(2, generalization.NewIntRangeGeneralizer(0, 100)),
+		})
+		for i := 0; i < 40; i++ {
+			table.AddRow(rnd.Intn(100), rnd.Intn(100))
+		}
+		anon := &Anonymizer{
+			Table: table,
+			K:     4,
+			Rand:  rand.New(rand.NewSource(42)),
+		}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertKAnonymity(table, 4, t)
+		return table.String()
+	}
+	first := anonymize()
+	for i := 0; i < 3; i++ {
+		if next := anonymize(); next != first {
+			t.Fatalf("expected identical results, got:\n%s\nand:\n%s", first, next)
+		}
+	}
+}
+
+func TestAnonymizer_Cost(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	table := model.NewTable(&model.Schema{
+		Columns: makeCols(2, generalization.NewIntRangeGeneralizer(0, 100)),
+	})
+	for i := 0; i < 4; i++ {
+		table.AddRow(rnd.Intn(100), 1+i)
+		table.AddRow(rnd.Intn(100), 90+i)
+	}
+	// the cost ignores the first column, so the groups are formed by the second one
+	ignored := algorithm.CostFunc(func(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+		return 0, nil
+	})
+	anon := &Anonymizer{
+		Table: table,
+		K:     4,
+		Rand:  rand.New(rand.NewSource(1)),
+		Cost:  &algorithm.ColumnCost{Columns: map[string]algorithm.CostFunction{"col 0": ignored}},
+	}
+	if err := anon.Anonymize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertKAnonymity(table, 4, t)
+	for _, row := range table.GetRows() {
+		r := row.Data[1].(partition.Range)
+		if r.Max()-r.Min() > 10 {
+			t.Errorf("expected groups by the second column, got %v", row.Data[1])
+		}
+	}
+}
+
+func TestAnonymizer_Transform(t *testing.T) {
+	cipher, _ := transformation.NewFF1(make([]byte, 16), 10)
+	tr, _ := transformation.NewFPETransformer(cipher, transformation.Digits, nil)
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewTransformedColumn("Card", tr),
+			model.NewColumn("Score", generalization.ExampleIntGeneralizer()),
+		},
+	})
+	table.AddRow("4111-1111-1111-1111", 1)
+	table.AddRow("5500-0000-0000-0004", 2)
+	anon := &Anonymizer{
+		Table: table,
+		K:     2,
+	}
+	if err := anon.Anonymize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := table.GetRows()[0].Data[0].String()
+	if first == "4111-1111-1111-1111" || len(first) != 19 {
+		t.Errorf("unexpected transformed value: %v", first)
+	}
+
+	t.Run("rows are only transformed once", func(t *testing.T) {
+		table.AddRow("3400-0000-0000-0009", 3)
+		table.AddRow("6011-0000-0000-0004", 4)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		actual := table.GetRows()[0].Data[0].String()
+		if first != actual {
+			t.Errorf("expected %v, got %v", first, actual)
+		}
+		decrypted, _ := tr.Decrypt(table.GetRows()[2].Data[0].String())
+		if decrypted != "3400-0000-0000-0009" {
+			t.Errorf("expected %v, got %v", "3400-0000-0000-0009", decrypted)
+		}
+	})
+
+	t.Run("transformation error", func(t *testing.T) {
+		table.AddRow("12", 5)
+		table.AddRow("34", 6)
+		if err := anon.Anonymize(); err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+}
+
+func TestAnonymizer_TransformError(t *testing.T) {
+	cipher, _ := transformation.NewFF1(make([]byte, 16), 10)
+	tr, _ := transformation.NewFPETransformer(cipher, transformation.Digits, nil)
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewTransformedColumn("Card", tr),
+			model.NewColumn("Score", generalization.ExampleIntGeneralizer()),
+		},
+	})
+	table.AddRow("4111-1111-1111-1111", 1)
+	table.AddRow("12", 2)
+	anon := &Anonymizer{
+		Table: table,
+		K:     2,
+	}
+	if err := anon.Anonymize(); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if actual := table.GetRows()[0].Data[0].String(); actual != "4111-1111-1111-1111" {
+		t.Errorf("expected %v, got %v", "4111-1111-1111-1111", actual)
+	}
+
+	t.Run("rows are transformed once by the next call", func(t *testing.T) {
+		table.GetRows()[1].Data[0] = partition.NewItem("5500-0000-0000-0004")
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		decrypted, _ := tr.Decrypt(table.GetRows()[0].Data[0].String())
+		if decrypted != "4111-1111-1111-1111" {
+			t.Errorf("expected %v, got %v", "4111-1111-1111-1111", decrypted)
+		}
+	})
 }
 
 func assertKAnonymity(table *model.Table, k int, t *testing.T) {
@@ -0,0 +1,119 @@
+package kanon
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// mergeFunc generalizes items into a single item, which covers all of them, and returns
+// the information loss of the generalization between 0 and 1.
+type mergeFunc[T any] func(items ...T) (T, float64, error)
+
+// kMemberClustering groups the items with greedy k-member clustering. Each cluster starts with the
+// item furthest from the previous seed, and grows with the item, which adds the least information
+// loss, until it has k members. The remaining items are added to the cluster where they add the
+// least information loss, so each cluster contains between k and 2k-1 items.
+func kMemberClustering[T any](items []T, k int, merge mergeFunc[T]) ([][]int, error) {
+	remaining := make([]int, len(items))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	var clusters [][]int
+	var generalized []T
+	seed := 0
+	for len(remaining) >= k {
+		remaining = removeIndex(remaining, seed)
+		cluster := []int{seed}
+		current := items[seed]
+		for len(cluster) < k {
+			best, merged, err := closestItem(current, items, remaining, false, merge)
+			if err != nil {
+				return nil, err
+			}
+			remaining = removeIndex(remaining, best)
+			cluster = append(cluster, best)
+			current = merged
+		}
+		clusters = append(clusters, cluster)
+		generalized = append(generalized, current)
+		if len(remaining) > 0 {
+			next, _, err := closestItem(items[seed], items, remaining, true, merge)
+			if err != nil {
+				return nil, err
+			}
+			seed = next
+		}
+	}
+	for _, idx := range remaining {
+		best, bestLoss := -1, math.Inf(1)
+		for i, current := range generalized {
+			_, loss, err := merge(current, items[idx])
+			if err != nil {
+				return nil, err
+			}
+			if loss < bestLoss {
+				best, bestLoss = i, loss
+			}
+		}
+		clusters[best] = append(clusters[best], idx)
+	}
+	return clusters, nil
+}
+
+// closestItem returns the candidate with the least information loss, when merged with the given
+// item, or the one with the most information loss, when furthest is set.
+func closestItem[T any](item T, items []T, candidates []int, furthest bool, merge mergeFunc[T]) (int, T, error) {
+	best, bestLoss := -1, 0.0
+	var bestMerged T
+	for _, idx := range candidates {
+		merged, loss, err := merge(item, items[idx])
+		if err != nil {
+			return 0, bestMerged, err
+		}
+		if best < 0 || (!furthest && loss < bestLoss) || (furthest && loss > bestLoss) {
+			best, bestLoss, bestMerged = idx, loss, merged
+		}
+	}
+	return best, bestMerged, nil
+}
+
+// commonGeneralization returns the lowest level of the generalizer, where all partitions are
+// generalized into the same partition, along with the partition itself.
+func commonGeneralization(g generalization.Generalizer, partitions []partition.Partition) (partition.Partition, int, error) {
+	for level := 0; level < g.Levels(); level++ {
+		common := g.Generalize(partitions[0], level)
+		if common == nil {
+			continue
+		}
+		same := true
+		for _, p := range partitions[1:] {
+			if q := g.Generalize(p, level); q == nil || !q.Equals(common) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return common, level, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("no common generalization for %v", partitions)
+}
+
+func levelLoss(g generalization.Generalizer, level int) float64 {
+	if g.Levels() < 2 {
+		return 0
+	}
+	return float64(level) / float64(g.Levels()-1)
+}
+
+func removeIndex(indices []int, idx int) []int {
+	for i, v := range indices {
+		if v == idx {
+			return append(indices[:i], indices[i+1:]...)
+		}
+	}
+	return indices
+}
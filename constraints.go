@@ -0,0 +1,135 @@
+package kanon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+// ConstraintError is returned, when the Table cannot be K-anonymized within the generalization
+// limits of the columns (see model.Column.WithMaxLevel). Rows contains the indices of the
+// blocking rows: the rows, which have less than K-1 other rows they could be generalized
+// together with.
+type ConstraintError struct {
+	K    int
+	Rows []int
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("cannot achieve %d-anonymity within the generalization limits of the columns, blocking rows: %v", e.K, e.Rows)
+}
+
+// Suppressed returns the indices of the rows, which were suppressed because of the
+// generalization limits of the columns. Suppressed rows stay suppressed, and are left out of
+// the later runs. In Incremental mode rows are never suppressed, they are pending instead
+// (see Pending).
+// The identifier columns of the suppressed rows are all '*', so they form a group of their
+// own, which has less than K rows, when less than K rows are suppressed. Only the rows,
+// which are not suppressed, are guaranteed to be K-anonymous.
+func (a *Anonymizer) Suppressed() []int {
+	return a.suppressed
+}
+
+// blocks divides the rows into blocks, which can be generalized into the same partitions
+// within the generalization limits of the columns. Generalization is monotone, so two rows can
+// be generalized together exactly when their partitions are the same at the limit of each column.
+// Blocks of less than K rows are returned separately, and so are the rows with values, which
+// cannot be generalized at the limit, each in a block of its own. Without limits all rows are
+// in one block, which is small itself, when there are less than K rows.
+func (a *Anonymizer) blocks(rows []int) ([][]int, [][]int) {
+	limited := false
+	for _, col := range a.Table.GetSchema().Columns {
+		limited = limited || col.IsLimited()
+	}
+	if !limited {
+		if len(rows) < a.K {
+			return nil, [][]int{rows}
+		}
+		return [][]int{rows}, nil
+	}
+	var keys []string
+	var ungeneralizable [][]int
+	blocks := make(map[string][]int)
+	for _, rowIdx := range rows {
+		key, ok := a.blockKey(a.Table.GetRows()[rowIdx].Data)
+		if !ok {
+			ungeneralizable = append(ungeneralizable, []int{rowIdx})
+			continue
+		}
+		if _, ok := blocks[key]; !ok {
+			keys = append(keys, key)
+		}
+		blocks[key] = append(blocks[key], rowIdx)
+	}
+	var large, small [][]int
+	for _, key := range keys {
+		if len(blocks[key]) < a.K {
+			small = append(small, blocks[key])
+		} else {
+			large = append(large, blocks[key])
+		}
+	}
+	return large, append(small, ungeneralizable...)
+}
+
+// blockKey returns the partitions of the row at the limits of the columns as a string.
+// It returns false, when a value cannot be generalized at the limit of its column.
+func (a *Anonymizer) blockKey(data []partition.Partition) (string, bool) {
+	key := &strings.Builder{}
+	for colIdx, col := range a.Table.GetSchema().Columns {
+		if !col.IsLimited() {
+			continue
+		}
+		p := col.GetGeneralizer().Generalize(data[colIdx], col.GetMaxLevel())
+		if p == nil {
+			return "", false
+		}
+		key.WriteString(p.String())
+		key.WriteString("\t")
+	}
+	return key.String(), true
+}
+
+// checkBlocked returns a ConstraintError for the blocking rows, unless they are suppressed.
+func (a *Anonymizer) checkBlocked(blocked []int) error {
+	if len(blocked) > 0 && !a.Suppress {
+		return &ConstraintError{K: a.K, Rows: blocked}
+	}
+	return nil
+}
+
+// suppress replaces the identifier columns of the rows with the '*' token.
+// Suppressed rows are not part of any group.
+func (a *Anonymizer) suppress(rows []int) {
+	a.suppressIdentifiers(rows)
+	a.suppressed = append(a.suppressed, rows...)
+}
+
+// unsuppressed returns the indices of the rows, which were not suppressed by a previous run.
+// Suppressed rows stay suppressed, they are left out of the later runs.
+func (a *Anonymizer) unsuppressed() []int {
+	skip := make(map[int]bool, len(a.suppressed))
+	for _, rowIdx := range a.suppressed {
+		skip[rowIdx] = true
+	}
+	var rows []int
+	for rowIdx := range a.Table.GetRows() {
+		if !skip[rowIdx] {
+			rows = append(rows, rowIdx)
+		}
+	}
+	return rows
+}
+
+// suppressIdentifiers replaces the identifier columns of the rows with the '*' token.
+func (a *Anonymizer) suppressIdentifiers(rows []int) {
+	for _, rowIdx := range rows {
+		row := a.Table.GetRows()[rowIdx]
+		for colIdx, col := range a.Table.GetSchema().Columns {
+			if col.IsIdentifier() {
+				row.Data[colIdx] = partition.NewItem("*")
+			}
+		}
+	}
+}
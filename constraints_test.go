@@ -0,0 +1,171 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+// getLimitedTable returns a table, where the ages may be generalized into bands of at most
+// 25 years, and the grades at most to the letter. The last row is the only row with a
+// young age and a C grade, so it cannot be 2-anonymized within the limits.
+func getLimitedTable() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 99)).WithMaxLevel(5),
+			model.NewColumn("Grade", generalization.ExampleGradeGeneralizer()).WithMaxLevel(1),
+			model.NewColumn("Name", nil),
+		},
+	})
+	table.AddRow(20, "A", "Alice")
+	table.AddRow(22, "A+", "Bob")
+	table.AddRow(23, "B", "Carol")
+	table.AddRow(24, "B-", "Dave")
+	table.AddRow(70, "A", "Eve")
+	table.AddRow(72, "A-", "Frank")
+	table.AddRow(90, "C", "Grace")
+	table.AddRow(80, "C+", "Heidi")
+	table.AddRow(5, "C", "Ivan")
+	return table
+}
+
+func TestAnonymizer_Limits(t *testing.T) {
+
+	t.Run("blocking rows", func(t *testing.T) {
+		table := getLimitedTable()
+		before := table.String()
+		anon := &Anonymizer{Table: table, K: 2, Rand: rand.New(rand.NewSource(1))}
+		err := anon.Anonymize()
+		var constraintErr *ConstraintError
+		if !errors.As(err, &constraintErr) {
+			t.Fatalf("expected constraint error, got %v", err)
+		}
+		testutil.AssertEquals("[8]", fmt.Sprint(constraintErr.Rows), t)
+		testutil.AssertEquals(before, table.String(), t)
+	})
+
+	t.Run("suppress blocking rows", func(t *testing.T) {
+		table := getLimitedTable()
+		originals := getLimitedTable()
+		anon := &Anonymizer{Table: table, K: 2, Suppress: true, Rand: rand.New(rand.NewSource(1))}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[8]", fmt.Sprint(anon.Suppressed()), t)
+		suppressed := table.GetRows()[8]
+		testutil.AssertEquals("*", suppressed.Data[0].String(), t)
+		testutil.AssertEquals("*", suppressed.Data[1].String(), t)
+		testutil.AssertEquals("Ivan", suppressed.Data[2].String(), t)
+		assertKAnonymity(table.Select([]int{0, 1, 2, 3, 4, 5, 6, 7}), 2, t)
+		assertWithinLimits(t, originals, table, 8)
+
+		anon.Table = getLimitedTable()
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[8]", fmt.Sprint(anon.Suppressed()), t)
+	})
+
+	t.Run("suppressed rows stay suppressed", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: []*model.Column{
+				model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 127)).WithMaxLevel(3),
+			},
+		})
+		for _, age := range []int{1, 2, 3, 4, 50, 100} {
+			table.AddRow(age)
+		}
+		anon := &Anonymizer{Table: table, K: 2, Suppress: true, Rand: rand.New(rand.NewSource(1))}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[4 5]", fmt.Sprint(anon.Suppressed()), t)
+
+		table.AddRow(5)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[4 5]", fmt.Sprint(anon.Suppressed()), t)
+		testutil.AssertEquals("*", table.GetRows()[4].Data[0].String(), t)
+		testutil.AssertEquals("*", table.GetRows()[5].Data[0].String(), t)
+		assertKAnonymity(table.Select([]int{0, 1, 2, 3, 6}), 2, t)
+	})
+
+	t.Run("values out of the domain are blocking", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: []*model.Column{
+				model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 127)).WithMaxLevel(3),
+			},
+		})
+		for _, age := range []int{1, 2, 200, 200} {
+			table.AddRow(age)
+		}
+		anon := &Anonymizer{Table: table, K: 1, Rand: rand.New(rand.NewSource(1))}
+		err := anon.Anonymize()
+		var constraintErr *ConstraintError
+		if !errors.As(err, &constraintErr) {
+			t.Fatalf("expected constraint error, got %v", err)
+		}
+		testutil.AssertEquals("[2 3]", fmt.Sprint(constraintErr.Rows), t)
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		table := getLimitedTable()
+		originals := getLimitedTable()
+		anon := &Anonymizer{Table: table, K: 2, Incremental: true, Rand: rand.New(rand.NewSource(1))}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[8]", fmt.Sprint(anon.Pending()), t)
+		testutil.AssertEquals("*", table.GetRows()[8].Data[0].String(), t)
+		testutil.AssertEquals("*", table.GetRows()[8].Data[1].String(), t)
+		assertKAnonymity(table.Select([]int{0, 1, 2, 3, 4, 5, 6, 7}), 2, t)
+
+		table.AddRow(6, "C", "Judy")
+		originals.AddRow(6, "C", "Judy")
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[]", fmt.Sprint(anon.Pending()), t)
+
+		table.AddRow(71, "A+", "Mallory")
+		originals.AddRow(71, "A+", "Mallory")
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[]", fmt.Sprint(anon.Pending()), t)
+		testutil.AssertEquals(0, len(anon.Suppressed()), t)
+		assertKAnonymity(table, 2, t)
+		assertWithinLimits(t, originals, table)
+	})
+}
+
+// assertWithinLimits checks that the rows of the anonymized table are not generalized
+// beyond the limit of each column, except for the suppressed rows.
+func assertWithinLimits(t *testing.T, original, anonymized *model.Table, suppressed ...int) {
+	t.Helper()
+	skip := make(map[int]bool)
+	for _, rowIdx := range suppressed {
+		skip[rowIdx] = true
+	}
+	for rowIdx, row := range anonymized.GetRows() {
+		if skip[rowIdx] {
+			continue
+		}
+		for colIdx, col := range anonymized.GetSchema().Columns {
+			if !col.IsLimited() {
+				continue
+			}
+			limit := col.GetGeneralizer().Generalize(original.GetRows()[rowIdx].Data[colIdx], col.GetMaxLevel())
+			p := row.Data[colIdx]
+			if !limit.Equals(p) && !limit.ContainsPartition(p) {
+				t.Errorf("row %d, column %s: %v is beyond the limit %v", rowIdx, col.GetName(), p, limit)
+			}
+		}
+	}
+}
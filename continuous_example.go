@@ -10,6 +10,8 @@ import (
 // ExampleContinuousAnonymization demonstrates the usage of continuos anonymization.
 // Usage is very similar to what is outlined in the function ExampleAnonymization,
 // with the ability to repeatedly add extra data to the table and call the Anonymmize method.
+// In incremental mode new rows join the groups of the previous calls where possible,
+// and rows which were already anonymized are never generalized to less specific partitions.
 func ExampleContinuousAnonymization() {
 
 	// define the schema & Table
@@ -29,8 +31,9 @@ func ExampleContinuousAnonymization() {
 	})
 
 	anon := &Anonymizer{
-		Table: table,
-		K:     2,
+		Table:       table,
+		K:           2,
+		Incremental: true,
 	}
 
 	// anonymize first chunk of data
@@ -79,13 +82,13 @@ func ExampleContinuousAnonymization() {
 	// the resulting Table will be similar to the below:
 
 	//	Name	Status		Gender	Age			Kids	Income			A-Index					Z-Index					Grade		Motto
-	//	*		employee	male	[18..36]	[0..2]	[10000..50000]	(0.000000..1.000000)	(-0.350000)				[A]			cats are
-	//	*		client		female	[18..36]	[0..2]	[15000..15624]	(0.000000..1.000000)	(-0.312500..-0.250000)	[A-]		cats are my
+	//	*		employee	*		[25]		[0]		[15000..17499]	(0.187500..0.250000)	(-0.375000..-0.250000)	[A, A+, A-]	cats are
+	//	*		client		*		[25]		[0]		[15000..17499]	(0.187500..0.250000)	(-0.375000..-0.250000)	[A, A+, A-]	cats are
 	//	*		employee	*		[30]		[2]		[30000..39999]	(0.600000)				(-0.500000..0.000000)	[A, A+, A-]	cats are
-	//	*		employee	*		[18..36]	[1..2]	[30000..50000]	(0.900000)				(-0.312500..-0.250000)	[A, A+, A-]	cats are
-	//	*		client		male	[18..36]	[0..2]	[10000..50000]	(0.000000..1.000000)	(-0.350000)				[A]			cats are
-	//	*		client		female	[18..36]	[0..2]	[15000..15624]	(0.000000..1.000000)	(-0.312500..-0.250000)	[A-]		cats are my
-	//	*		employee	*		[18..36]	[1..2]	[30000..50000]	(0.900000)				(-0.312500..-0.250000)	[A, A+, A-]	cats are
+	//	*		employee	female	[27..31]	[1]		[10000..50000]	(0.500000..1.000000)	(-0.312500..-0.250000)	[A, A+, A-]	cats are
+	//	*		client		male	[18..36]	[2]		[40000..44999]	(0.900000)				(-0.375000..-0.250000)	[A, A+, A-]	cats are
+	//	*		client		female	[27..31]	[1]		[10000..50000]	(0.500000..1.000000)	(-0.312500..-0.250000)	[A, A+, A-]	cats are
+	//	*		employee	male	[18..36]	[2]		[40000..44999]	(0.900000)				(-0.375000..-0.250000)	[A, A+, A-]	cats are
 	//	*		client		*		[30]		[2]		[30000..39999]	(0.600000)				(-0.500000..0.000000)	[A, A+, A-]	cats are
 	//	*		employee	female	[27..31]	[1..2]	[21875..22499]	(0.000000..1.000000)	(-0.200000)				[B, B+, B-]	*
 	//	*		client		male	[0..74]		[0..2]	[37500..39999]	(0.000000..1.000000)	(-0.150000)				[A, A+, A-]	dogs are
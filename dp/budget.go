@@ -0,0 +1,52 @@
+package dp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExhausted is returned when a query would exceed the privacy budget.
+var ErrBudgetExhausted = errors.New("privacy budget exhausted")
+
+// Budget tracks the privacy loss (ε, δ) of the queries answered on a dataset.
+// The privacy losses of the queries add up (sequential composition), and queries
+// are refused once they would exceed the total budget. A Budget is safe for
+// concurrent use.
+type Budget struct {
+	epsilon, delta           float64
+	spentEpsilon, spentDelta float64
+	mu                       sync.Mutex
+}
+
+// NewBudget creates a privacy budget with the given total ε and δ.
+func NewBudget(epsilon, delta float64) (*Budget, error) {
+	if epsilon <= 0 || delta < 0 || delta >= 1 {
+		return nil, fmt.Errorf("invalid privacy budget: ε=%v, δ=%v", epsilon, delta)
+	}
+	return &Budget{epsilon: epsilon, delta: delta}, nil
+}
+
+// Spend deducts the given privacy loss from the budget, or returns ErrBudgetExhausted
+// without deducting anything, if the remaining budget is not enough.
+func (b *Budget) Spend(epsilon, delta float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spentEpsilon+epsilon > b.epsilon*(1+tolerance) || b.spentDelta+delta > b.delta*(1+tolerance) {
+		return fmt.Errorf("%w: spending ε=%v, δ=%v with ε=%v, δ=%v remaining",
+			ErrBudgetExhausted, epsilon, delta, b.epsilon-b.spentEpsilon, b.delta-b.spentDelta)
+	}
+	b.spentEpsilon += epsilon
+	b.spentDelta += delta
+	return nil
+}
+
+// Remaining returns the privacy budget, which is not spent yet.
+func (b *Budget) Remaining() (epsilon, delta float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.epsilon - b.spentEpsilon, b.delta - b.spentDelta
+}
+
+// tolerance allows spending the whole budget in parts, despite rounding errors.
+const tolerance = 1e-9
@@ -0,0 +1,51 @@
+package dp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestNewBudget(t *testing.T) {
+	tests := []struct {
+		epsilon, delta float64
+		valid          bool
+	}{
+		{1, 0, true},
+		{0.5, 1e-5, true},
+		{0, 0, false},
+		{-1, 0, false},
+		{1, -0.1, false},
+		{1, 1, false},
+	}
+	for _, test := range tests {
+		_, err := NewBudget(test.epsilon, test.delta)
+		testutil.AssertEquals(test.valid, err == nil, t)
+	}
+}
+
+func TestBudget_Spend(t *testing.T) {
+
+	t.Run("spend whole budget in parts", func(t *testing.T) {
+		b, _ := NewBudget(1, 1e-5)
+		for i := 0; i < 10; i++ {
+			if err := b.Spend(0.1, 1e-6); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := b.Spend(0.1, 0); !errors.Is(err, ErrBudgetExhausted) {
+			t.Errorf("expected exhausted budget, got %v", err)
+		}
+	})
+
+	t.Run("refused query does not spend", func(t *testing.T) {
+		b, _ := NewBudget(1, 0)
+		if err := b.Spend(0.5, 1e-6); !errors.Is(err, ErrBudgetExhausted) {
+			t.Errorf("expected exhausted budget, got %v", err)
+		}
+		epsilon, delta := b.Remaining()
+		testutil.AssertEquals(1.0, epsilon, t)
+		testutil.AssertEquals(0.0, delta, t)
+	})
+}
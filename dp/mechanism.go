@@ -0,0 +1,106 @@
+package dp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Mechanism adds random noise to query results to make them differentially private.
+type Mechanism interface {
+	// Randomize returns the value with noise added. The noise is calibrated to the
+	// L1 and L2 sensitivities of the query: the largest change of the result, when
+	// a single row is added to or removed from the table.
+	Randomize(value, l1, l2 float64, rnd *rand.Rand) float64
+
+	// Privacy returns the privacy loss (ε, δ) of one application of the mechanism.
+	Privacy() (epsilon, delta float64)
+}
+
+// Laplace is the Laplace mechanism, which provides ε-differential privacy.
+type Laplace struct {
+	epsilon float64
+}
+
+// NewLaplace creates a Laplace mechanism with the given ε.
+func NewLaplace(epsilon float64) (*Laplace, error) {
+	if epsilon <= 0 {
+		return nil, fmt.Errorf("invalid ε for Laplace mechanism: %v", epsilon)
+	}
+	return &Laplace{epsilon: epsilon}, nil
+}
+
+// Randomize adds noise from the Laplace distribution with scale l1/ε.
+func (m *Laplace) Randomize(value, l1, _ float64, rnd *rand.Rand) float64 {
+	return value + laplace(l1/m.epsilon, rnd)
+}
+
+// Privacy returns the ε of the mechanism, and zero δ.
+func (m *Laplace) Privacy() (float64, float64) {
+	return m.epsilon, 0
+}
+
+// Gaussian is the Gaussian mechanism, which provides (ε, δ)-differential privacy for ε < 1.
+type Gaussian struct {
+	epsilon, delta float64
+}
+
+// NewGaussian creates a Gaussian mechanism with the given ε and δ.
+func NewGaussian(epsilon, delta float64) (*Gaussian, error) {
+	if epsilon <= 0 || epsilon >= 1 || delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("invalid ε or δ for Gaussian mechanism: %v, %v", epsilon, delta)
+	}
+	return &Gaussian{epsilon: epsilon, delta: delta}, nil
+}
+
+// Randomize adds noise from the normal distribution with standard deviation
+// l2 * sqrt(2 ln(1.25/δ)) / ε.
+func (m *Gaussian) Randomize(value, _, l2 float64, rnd *rand.Rand) float64 {
+	return value + rnd.NormFloat64()*m.sigma(l2)
+}
+
+// Privacy returns the ε and δ of the mechanism.
+func (m *Gaussian) Privacy() (float64, float64) {
+	return m.epsilon, m.delta
+}
+
+func (m *Gaussian) sigma(l2 float64) float64 {
+	return l2 * math.Sqrt(2*math.Log(1.25/m.delta)) / m.epsilon
+}
+
+// Exponential is the exponential mechanism, which selects one of the candidates with
+// ε-differential privacy, when the scores of the candidates change at most by the
+// sensitivity, when a single row is added to or removed from the table. It returns the
+// index of a candidate, picked with probability proportional to exp(ε score / 2Δ).
+func Exponential(scores []float64, epsilon, sensitivity float64, rnd *rand.Rand) int {
+	best := math.Inf(-1)
+	for _, s := range scores {
+		best = math.Max(best, s)
+	}
+	weights := make([]float64, len(scores))
+	var total float64
+	for i, s := range scores {
+		weights[i] = math.Exp(epsilon * (s - best) / (2 * sensitivity))
+		total += weights[i]
+	}
+	r := rnd.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// laplace samples the Laplace distribution with the given scale, by inverting its CDF.
+func laplace(scale float64, rnd *rand.Rand) float64 {
+	u := rnd.Float64() - 0.5
+	for u == -0.5 {
+		u = rnd.Float64() - 0.5
+	}
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
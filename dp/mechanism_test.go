@@ -0,0 +1,81 @@
+package dp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestNewLaplace(t *testing.T) {
+	_, err := NewLaplace(0)
+	testutil.AssertNotNil(err, t)
+	m, err := NewLaplace(0.5)
+	testutil.AssertNil(err, t)
+	epsilon, delta := m.Privacy()
+	testutil.AssertEquals(0.5, epsilon, t)
+	testutil.AssertEquals(0.0, delta, t)
+}
+
+func TestNewGaussian(t *testing.T) {
+	for _, params := range [][2]float64{{0, 0.1}, {1, 0.1}, {0.5, 0}, {0.5, 1}} {
+		_, err := NewGaussian(params[0], params[1])
+		testutil.AssertNotNil(err, t)
+	}
+	m, err := NewGaussian(0.5, 1e-5)
+	testutil.AssertNil(err, t)
+	epsilon, delta := m.Privacy()
+	testutil.AssertEquals(0.5, epsilon, t)
+	testutil.AssertEquals(1e-5, delta, t)
+}
+
+func TestExponential(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	scores := []float64{0, 1, 2}
+	counts := make([]int, len(scores))
+	n := 100000
+	for i := 0; i < n; i++ {
+		counts[Exponential(scores, 2, 1, rnd)]++
+	}
+	total := 1 + math.E + math.E*math.E
+	for i, count := range counts {
+		expected := math.Exp(scores[i]) / total
+		if actual := float64(count) / float64(n); math.Abs(actual-expected) > 0.01 {
+			t.Errorf("expected candidate %d with probability %v, got %v", i, expected, actual)
+		}
+	}
+}
+
+func TestMechanism_Randomize(t *testing.T) {
+	laplace, _ := NewLaplace(0.5)
+	gaussian, _ := NewGaussian(0.5, 1e-5)
+	tests := []struct {
+		name string
+		m    Mechanism
+		std  float64
+	}{
+		{"laplace", laplace, math.Sqrt2 * 2 / 0.5},
+		{"gaussian", gaussian, gaussian.sigma(2)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+			n := 100000
+			var sum, sumSq float64
+			for i := 0; i < n; i++ {
+				v := test.m.Randomize(10, 2, 2, rnd) - 10
+				sum += v
+				sumSq += v * v
+			}
+			mean := sum / float64(n)
+			std := math.Sqrt(sumSq/float64(n) - mean*mean)
+			if math.Abs(mean) > 0.1*test.std {
+				t.Errorf("unexpected mean of noise: %v", mean)
+			}
+			if math.Abs(std-test.std) > 0.05*test.std {
+				t.Errorf("expected standard deviation %v, got %v", test.std, std)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package dp
+
+import (
+	"fmt"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Predicate selects the rows of a table, which a query is computed on.
+// A predicate is bound to the schema of the queried table once per query, and
+// the returned function is called with each row of the table.
+type Predicate func(schema *model.Schema) (func(row *model.Row) bool, error)
+
+// All selects every row.
+func All() Predicate {
+	return func(*model.Schema) (func(*model.Row) bool, error) {
+		return func(*model.Row) bool { return true }, nil
+	}
+}
+
+// Contains selects the rows, which partition in the given column contains the item.
+// On a generalized table this also selects the rows, which were generalized away
+// from the item, but may still contain it.
+func Contains(column string, item interface{}) Predicate {
+	return columnPredicate(column, func(p partition.Partition) bool {
+		return p.Contains(item)
+	})
+}
+
+// Equals selects the rows, which partition in the given column equals the given partition.
+func Equals(column string, q partition.Partition) Predicate {
+	return columnPredicate(column, func(p partition.Partition) bool {
+		return q.Equals(p)
+	})
+}
+
+// Within selects the rows, which partition in the given column is equal to or
+// contained in the given partition.
+func Within(column string, q partition.Partition) Predicate {
+	return columnPredicate(column, func(p partition.Partition) bool {
+		return q.Equals(p) || q.ContainsPartition(p)
+	})
+}
+
+// And selects the rows, which are selected by all the given predicates.
+func And(predicates ...Predicate) Predicate {
+	return combine(predicates, func(results []bool) bool {
+		for _, r := range results {
+			if !r {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or selects the rows, which are selected by any of the given predicates.
+func Or(predicates ...Predicate) Predicate {
+	return combine(predicates, func(results []bool) bool {
+		for _, r := range results {
+			if r {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not selects the rows, which are not selected by the given predicate.
+func Not(predicate Predicate) Predicate {
+	return combine([]Predicate{predicate}, func(results []bool) bool {
+		return !results[0]
+	})
+}
+
+func columnPredicate(column string, match func(p partition.Partition) bool) Predicate {
+	return func(schema *model.Schema) (func(*model.Row) bool, error) {
+		colIdx := schema.IndexOf(column)
+		if colIdx < 0 {
+			return nil, fmt.Errorf("unknown column: %s", column)
+		}
+		return func(row *model.Row) bool {
+			return match(row.Data[colIdx])
+		}, nil
+	}
+}
+
+func combine(predicates []Predicate, reduce func(results []bool) bool) Predicate {
+	return func(schema *model.Schema) (func(*model.Row) bool, error) {
+		bound := make([]func(*model.Row) bool, len(predicates))
+		for i, p := range predicates {
+			b, err := p(schema)
+			if err != nil {
+				return nil, err
+			}
+			bound[i] = b
+		}
+		return func(row *model.Row) bool {
+			results := make([]bool, len(bound))
+			for i, b := range bound {
+				results[i] = b(row)
+			}
+			return reduce(results)
+		}, nil
+	}
+}
@@ -0,0 +1,76 @@
+package dp
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestPredicate(t *testing.T) {
+	table := getTestTable()
+	tests := []struct {
+		name      string
+		predicate Predicate
+		expected  int
+	}{
+		{"all", All(), 6},
+		{"contains item", Contains("Gender", "female"), 3},
+		{"contains item in range", Contains("Age", 25), 4},
+		{"equals", Equals("Age", partition.NewIntRange(30, 39)), 2},
+		{"within", Within("Age", partition.NewIntRange(20, 29)), 4},
+		{"and", And(Contains("Gender", "male"), Contains("Age", 35)), 1},
+		{"or", Or(Contains("Gender", "male"), Contains("Age", 35)), 4},
+		{"not", Not(Contains("Gender", "male")), 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, err := test.predicate(table.GetSchema())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			count := 0
+			for _, row := range table.GetRows() {
+				if match(row) {
+					count++
+				}
+			}
+			testutil.AssertEquals(test.expected, count, t)
+		})
+	}
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := And(All(), Contains("Zip", 1234))(table.GetSchema())
+		testutil.AssertNotNil(err, t)
+	})
+}
+
+// getTestTable returns a small anonymized table.
+func getTestTable() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Gender", nil),
+			model.NewColumn("Age", nil),
+			model.NewColumn("Income", nil),
+		},
+	})
+	rows := []struct {
+		gender string
+		age    partition.Partition
+		income int
+	}{
+		{"male", partition.NewIntRange(20, 29), 1000},
+		{"female", partition.NewIntRange(20, 29), 2000},
+		{"male", partition.NewIntRange(20, 29), 3000},
+		{"female", partition.NewIntRange(20, 29), 4000},
+		{"male", partition.NewIntRange(30, 39), 5000},
+		{"female", partition.NewIntRange(30, 39), 6000},
+	}
+	for _, r := range rows {
+		table.AppendRows(&model.Row{Data: []partition.Partition{
+			partition.NewItem(r.gender), r.age, partition.NewItem(r.income),
+		}})
+	}
+	return table
+}
@@ -0,0 +1,181 @@
+package dp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Querier answers differentially private aggregate queries over a table, which is
+// typically an anonymized table. The privacy loss of each query is spent from the
+// Budget of the dataset before the query is answered, and queries are refused with
+// ErrBudgetExhausted once the budget is spent.
+// Rand is the source of the noise (defaults to a time-seeded source). A Querier is
+// not safe for concurrent use, but multiple queriers can share the same Budget.
+type Querier struct {
+	Table  *model.Table
+	Budget *Budget
+	Rand   *rand.Rand
+}
+
+// Bin is a bin of a histogram: the partitions of the histogram columns, and the
+// noisy number of rows with these partitions.
+type Bin struct {
+	Partitions []partition.Partition
+	Count      float64
+}
+
+// Count returns the noisy number of rows selected by the predicate.
+func (q *Querier) Count(where Predicate, m Mechanism) (float64, error) {
+	match, err := where(q.Table.GetSchema())
+	if err != nil {
+		return 0, err
+	}
+	if err := q.spend(m); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, row := range q.Table.GetRows() {
+		if match(row) {
+			count++
+		}
+	}
+	return m.Randomize(float64(count), 1, 1, q.rand()), nil
+}
+
+// Sum returns the noisy sum of the values in the given column of the rows selected
+// by the predicate. Values are clamped to [lower, upper], which bounds the sensitivity
+// of the query; the midpoint of ranges is used for generalized values.
+// The column must be a range column, or a column without a generalizer or transformer.
+// Values which are not numbers, like suppressed ones, are taken as zero, so neither the
+// answer, nor an error reveals which rows have them.
+func (q *Querier) Sum(column string, lower, upper float64, where Predicate, m Mechanism) (float64, error) {
+	if lower > upper {
+		return 0, fmt.Errorf("invalid bounds: [%v, %v]", lower, upper)
+	}
+	colIdx := q.Table.GetSchema().IndexOf(column)
+	if colIdx < 0 {
+		return 0, fmt.Errorf("unknown column: %s", column)
+	}
+	if !isNumericColumn(q.Table.GetSchema().Columns[colIdx]) {
+		return 0, fmt.Errorf("cannot sum column %s: not a numeric column", column)
+	}
+	match, err := where(q.Table.GetSchema())
+	if err != nil {
+		return 0, err
+	}
+	if err := q.spend(m); err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, row := range q.Table.GetRows() {
+		if match(row) {
+			sum += math.Max(lower, math.Min(upper, numericValue(row.Data[colIdx])))
+		}
+	}
+	sensitivity := math.Max(math.Abs(lower), math.Abs(upper))
+	return m.Randomize(sum, sensitivity, sensitivity, q.rand()), nil
+}
+
+// Histogram returns the noisy number of rows selected by the predicate for each
+// combination of partitions in the given columns. Using the identifier columns of
+// an anonymized table gives the size of each equivalence class.
+// The bins are taken from the table, which is only safe when the partitions of the
+// columns are public, like in a published anonymized table.
+// Each row is counted in a single bin, so the whole histogram spends the privacy
+// loss of one application of the mechanism.
+func (q *Querier) Histogram(where Predicate, m Mechanism, columns ...string) ([]*Bin, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("no histogram columns")
+	}
+	indices := make([]int, len(columns))
+	for i, column := range columns {
+		if indices[i] = q.Table.GetSchema().IndexOf(column); indices[i] < 0 {
+			return nil, fmt.Errorf("unknown column: %s", column)
+		}
+	}
+	match, err := where(q.Table.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	if err := q.spend(m); err != nil {
+		return nil, err
+	}
+	bins := make(map[string]*Bin)
+	for _, row := range q.Table.GetRows() {
+		partitions := make([]partition.Partition, len(indices))
+		keys := make([]string, len(indices))
+		for i, colIdx := range indices {
+			partitions[i] = row.Data[colIdx]
+			keys[i] = row.Data[colIdx].String()
+		}
+		key := strings.Join(keys, "\t")
+		bin, ok := bins[key]
+		if !ok {
+			bin = &Bin{Partitions: partitions}
+			bins[key] = bin
+		}
+		if match(row) {
+			bin.Count++
+		}
+	}
+	keys := make([]string, 0, len(bins))
+	for key := range bins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*Bin, len(keys))
+	for i, key := range keys {
+		bin := bins[key]
+		bin.Count = m.Randomize(bin.Count, 1, 1, q.rand())
+		result[i] = bin
+	}
+	return result, nil
+}
+
+func (q *Querier) spend(m Mechanism) error {
+	return q.Budget.Spend(m.Privacy())
+}
+
+func (q *Querier) rand() *rand.Rand {
+	if q.Rand == nil {
+		q.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return q.Rand
+}
+
+// isNumericColumn decides from the schema only, whether the column may hold numbers.
+func isNumericColumn(col *model.Column) bool {
+	if col.IsTransformed() {
+		return false
+	}
+	if !col.IsIdentifier() {
+		return true
+	}
+	_, ok := col.GetGeneralizer().(*generalization.RangeGeneralizer)
+	return ok
+}
+
+// numericValue returns the value of a number, or the midpoint of a range, and zero otherwise.
+func numericValue(p partition.Partition) float64 {
+	switch v := p.(type) {
+	case partition.Range:
+		return (v.Min() + v.Max()) / 2
+	case *partition.Item:
+		switch i := v.GetItem().(type) {
+		case int:
+			return float64(i)
+		case float64:
+			return i
+		}
+	}
+	return 0
+}
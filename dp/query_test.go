@@ -0,0 +1,159 @@
+package dp
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getTestQuerier(epsilon float64) *Querier {
+	budget, _ := NewBudget(epsilon, 1e-4)
+	return &Querier{
+		Table:  getTestTable(),
+		Budget: budget,
+		Rand:   rand.New(rand.NewSource(1)),
+	}
+}
+
+func TestQuerier_Count(t *testing.T) {
+
+	t.Run("noisy count", func(t *testing.T) {
+		q := getTestQuerier(1000)
+		m, _ := NewLaplace(100)
+		count, err := q.Count(Contains("Gender", "female"), m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(count-3) > 0.5 {
+			t.Errorf("expected count close to 3, got %v", count)
+		}
+	})
+
+	t.Run("budget exhausted", func(t *testing.T) {
+		q := getTestQuerier(1)
+		m, _ := NewLaplace(0.6)
+		if _, err := q.Count(All(), m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := q.Count(All(), m); !errors.Is(err, ErrBudgetExhausted) {
+			t.Errorf("expected exhausted budget, got %v", err)
+		}
+	})
+
+	t.Run("invalid predicate does not spend", func(t *testing.T) {
+		q := getTestQuerier(1)
+		m, _ := NewLaplace(1)
+		_, err := q.Count(Contains("Zip", 1234), m)
+		testutil.AssertNotNil(err, t)
+		epsilon, _ := q.Budget.Remaining()
+		testutil.AssertEquals(1.0, epsilon, t)
+	})
+}
+
+func TestQuerier_Sum(t *testing.T) {
+
+	t.Run("noisy sum", func(t *testing.T) {
+		q := getTestQuerier(1000)
+		m, _ := NewGaussian(0.9, 1e-5)
+		sum, err := q.Sum("Income", 0, 10000, Contains("Gender", "male"), m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(sum-9000) > 100000 {
+			t.Errorf("expected sum close to 9000, got %v", sum)
+		}
+	})
+
+	t.Run("clamped values", func(t *testing.T) {
+		q := getTestQuerier(1e7)
+		m, _ := NewLaplace(1e6)
+		sum, err := q.Sum("Income", 0, 2000, All(), m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(sum-11000) > 1 {
+			t.Errorf("expected sum close to 11000, got %v", sum)
+		}
+	})
+
+	t.Run("range midpoints", func(t *testing.T) {
+		q := getTestQuerier(1e7)
+		m, _ := NewLaplace(1e6)
+		sum, err := q.Sum("Age", 0, 100, All(), m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(sum-4*24.5-2*34.5) > 1 {
+			t.Errorf("expected sum close to 167, got %v", sum)
+		}
+	})
+
+	t.Run("non-numeric values are taken as zero", func(t *testing.T) {
+		q := getTestQuerier(1e7)
+		m, _ := NewLaplace(1e6)
+		sum, err := q.Sum("Gender", -1, 1, All(), m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if math.Abs(sum) > 1 {
+			t.Errorf("expected sum close to 0, got %v", sum)
+		}
+		epsilon, _ := q.Budget.Remaining()
+		testutil.AssertEquals(1e7-1e6, epsilon, t)
+	})
+
+	t.Run("non-numeric column does not spend", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: []*model.Column{model.NewColumn("Gender", &generalization.Suppressor{})},
+		})
+		table.AddRow("male")
+		budget, _ := NewBudget(1, 1e-4)
+		q := &Querier{Table: table, Budget: budget}
+		m, _ := NewLaplace(1)
+		_, err := q.Sum("Gender", 0, 1, All(), m)
+		testutil.AssertNotNil(err, t)
+		epsilon, _ := q.Budget.Remaining()
+		testutil.AssertEquals(1.0, epsilon, t)
+	})
+}
+
+func TestQuerier_Histogram(t *testing.T) {
+
+	t.Run("equivalence classes", func(t *testing.T) {
+		q := getTestQuerier(1e7)
+		m, _ := NewLaplace(1e6)
+		bins, err := q.Histogram(All(), m, "Age")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(2, len(bins), t)
+		testutil.AssertEquals("[20..29]", bins[0].Partitions[0].String(), t)
+		testutil.AssertEquals(4.0, math.Round(bins[0].Count), t)
+		testutil.AssertEquals(2.0, math.Round(bins[1].Count), t)
+	})
+
+	t.Run("bins do not depend on the predicate", func(t *testing.T) {
+		q := getTestQuerier(1e7)
+		m, _ := NewLaplace(1e6)
+		bins, err := q.Histogram(Contains("Age", 25), m, "Gender", "Age")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(4, len(bins), t)
+		testutil.AssertEquals(0.0, math.Round(bins[3].Count), t) // male [30..39]
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		q := getTestQuerier(1000)
+		m, _ := NewLaplace(1)
+		_, err := q.Histogram(All(), m)
+		testutil.AssertNotNil(err, t)
+		_, err = q.Histogram(All(), m, "Zip")
+		testutil.AssertNotNil(err, t)
+	})
+}
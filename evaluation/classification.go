@@ -0,0 +1,317 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Classification measures how the anonymization affects the data consumers, who train
+// classifiers on the data. A naive Bayes classifier predicting the Target column from the
+// Features (defaults to all other columns) is trained on the original and on the anonymized
+// rows, and both are tested on the same original rows, which are left out from the training.
+// Holdout is the part of the rows used for testing (defaults to 0.3), and Rand picks them
+// (defaults to a time-seeded source). Numeric features are divided into Bins equal-width
+// bins (defaults to 10). Generalized values count in each category or bin they cover, in
+// proportion to the covered part.
+type Classification struct {
+	Target   string
+	Features []string
+	Holdout  float64
+	Bins     int
+	Rand     *rand.Rand
+}
+
+// ClassificationReport contains the accuracy of the classifiers trained on the original and on
+// the anonymized rows, their difference, and the number of rows used for testing.
+type ClassificationReport struct {
+	OriginalAccuracy   float64
+	AnonymizedAccuracy float64
+	Difference         float64
+	Tests              int
+}
+
+// Evaluate trains and tests the classifiers. The anonymized table must contain the rows of the
+// original table in the same order, as the tables anonymized in place by the Anonymizer do.
+func (c *Classification) Evaluate(original, anonymized *model.Table) (*ClassificationReport, error) {
+	n := len(original.GetRows())
+	if n != len(anonymized.GetRows()) {
+		return nil, fmt.Errorf("the tables have different number of rows: %d, %d", n, len(anonymized.GetRows()))
+	}
+	target, err := newEncoder(original, c.Target, 0)
+	if err != nil {
+		return nil, err
+	}
+	var features []*encoder
+	for _, name := range c.features(original.GetSchema()) {
+		f, err := newEncoder(original, name, c.bins())
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, f)
+	}
+	if len(features) == 0 {
+		return nil, errors.New("no features to train on")
+	}
+	indices := c.rand().Perm(n)
+	tests := int(math.Round(c.holdout() * float64(n)))
+	if tests < 1 || tests >= n {
+		return nil, fmt.Errorf("cannot split %d rows for training and testing", n)
+	}
+	test, train := indices[:tests], indices[tests:]
+	var accuracy [2]float64
+	for i, table := range []*model.Table{original, anonymized} {
+		nb, err := trainNaiveBayes(table, train, target, features)
+		if err != nil {
+			return nil, err
+		}
+		correct := 0
+		for _, row := range test {
+			data := original.GetRows()[row].Data
+			if nb.predict(data) == target.category(data[target.column]) {
+				correct++
+			}
+		}
+		accuracy[i] = float64(correct) / float64(tests)
+	}
+	return &ClassificationReport{
+		OriginalAccuracy:   accuracy[0],
+		AnonymizedAccuracy: accuracy[1],
+		Difference:         accuracy[0] - accuracy[1],
+		Tests:              tests,
+	}, nil
+}
+
+func (c *Classification) features(schema *model.Schema) []string {
+	if len(c.Features) > 0 {
+		return c.Features
+	}
+	var names []string
+	for _, col := range schema.Columns {
+		if col.GetName() != c.Target {
+			names = append(names, col.GetName())
+		}
+	}
+	return names
+}
+
+func (c *Classification) holdout() float64 {
+	if c.Holdout <= 0 || c.Holdout >= 1 {
+		return 0.3
+	}
+	return c.Holdout
+}
+
+func (c *Classification) bins() int {
+	if c.Bins <= 0 {
+		return 10
+	}
+	return c.Bins
+}
+
+func (c *Classification) rand() *rand.Rand {
+	if c.Rand == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return c.Rand
+}
+
+// encoder maps the partitions of a column to weights of categories. The categories are the
+// distinct original values of the column, or equal-width bins if the column is numeric.
+type encoder struct {
+	column     int
+	categories []partition.Partition // original values of categorical columns
+	keys       map[string]int
+	lo, hi     float64 // range of numeric columns
+	bins       int
+	integer    bool
+}
+
+// newEncoder creates an encoder from the original values of a column. Numeric columns are
+// binned, unless bins is zero.
+func newEncoder(original *model.Table, name string, bins int) (*encoder, error) {
+	colIdx := original.GetSchema().IndexOf(name)
+	if colIdx < 0 {
+		return nil, fmt.Errorf("unknown column: %s", name)
+	}
+	e := &encoder{column: colIdx, keys: make(map[string]int), lo: math.Inf(1), hi: math.Inf(-1), integer: true}
+	numeric := bins > 0
+	for rowIdx, row := range original.GetRows() {
+		p := row.Data[colIdx]
+		v, err := originalValue(p)
+		if err != nil {
+			return nil, fmt.Errorf("column %s, row %d: %w", name, rowIdx, err)
+		}
+		switch x := v.(type) {
+		case int:
+			e.lo, e.hi = math.Min(e.lo, float64(x)), math.Max(e.hi, float64(x))
+		case float64:
+			e.lo, e.hi = math.Min(e.lo, x), math.Max(e.hi, x)
+			e.integer = false
+		default:
+			numeric = false
+		}
+		if _, ok := e.keys[p.String()]; !ok {
+			e.keys[p.String()] = len(e.categories)
+			e.categories = append(e.categories, p)
+		}
+	}
+	if numeric && len(e.categories) > bins {
+		e.bins = bins
+		if e.integer {
+			e.hi++ // integer v covers [v, v+1)
+		}
+	}
+	return e, nil
+}
+
+func (e *encoder) size() int {
+	if e.bins > 0 {
+		return e.bins
+	}
+	return len(e.categories)
+}
+
+// category returns the category of an original value.
+func (e *encoder) category(p partition.Partition) int {
+	w := e.weights(p)
+	best := 0
+	for i := range w {
+		if w[i] > w[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// weights spreads a partition over the categories it covers. If it covers none of them,
+// it is spread over all categories.
+func (e *encoder) weights(p partition.Partition) []float64 {
+	w := make([]float64, e.size())
+	if e.bins > 0 {
+		e.binWeights(p, w)
+	} else if i, ok := e.keys[p.String()]; ok {
+		w[i] = 1
+	} else {
+		for i, c := range e.categories {
+			v, _ := originalValue(c)
+			if isSuppressed(p) || p.Contains(v) {
+				w[i] = 1
+			}
+		}
+	}
+	var total float64
+	for _, x := range w {
+		total += x
+	}
+	for i := range w {
+		if total > 0 {
+			w[i] /= total
+		} else {
+			w[i] = 1 / float64(len(w))
+		}
+	}
+	return w
+}
+
+func (e *encoder) binWeights(p partition.Partition, w []float64) {
+	var lo, hi float64
+	switch q := p.(type) {
+	case *partition.IntRange:
+		lo, hi = q.Min(), q.Max()+1
+	case *partition.FloatRange:
+		lo, hi = q.Min(), q.Max()
+	case *partition.Item:
+		switch v := q.GetItem().(type) {
+		case int:
+			lo, hi = float64(v), float64(v+1)
+		case float64:
+			lo, hi = v, v
+		default:
+			return
+		}
+	default:
+		return
+	}
+	width := (e.hi - e.lo) / float64(e.bins)
+	if lo == hi {
+		i := int((lo - e.lo) / width)
+		w[max(0, min(e.bins-1, i))] = 1
+		return
+	}
+	for i := range w {
+		from := e.lo + float64(i)*width
+		w[i] = math.Max(0, math.Min(hi, from+width)-math.Max(lo, from))
+	}
+}
+
+// naiveBayes holds the weighted number of training rows in each class, and in each
+// category of each feature for each class.
+type naiveBayes struct {
+	target   *encoder
+	features []*encoder
+	classes  []float64
+	counts   [][][]float64 // class, feature, category
+}
+
+func trainNaiveBayes(table *model.Table, rows []int, target *encoder, features []*encoder) (*naiveBayes, error) {
+	nb := &naiveBayes{
+		target:   target,
+		features: features,
+		classes:  make([]float64, target.size()),
+		counts:   make([][][]float64, target.size()),
+	}
+	for c := range nb.counts {
+		nb.counts[c] = make([][]float64, len(features))
+		for f, e := range features {
+			nb.counts[c][f] = make([]float64, e.size())
+		}
+	}
+	for _, rowIdx := range rows {
+		data := table.GetRows()[rowIdx].Data
+		if len(data) <= target.column {
+			return nil, fmt.Errorf("row %d has %d columns", rowIdx, len(data))
+		}
+		classes := target.weights(data[target.column])
+		for f, e := range features {
+			categories := e.weights(data[e.column])
+			for c, cw := range classes {
+				if cw == 0 {
+					continue
+				}
+				for i, w := range categories {
+					nb.counts[c][f][i] += cw * w
+				}
+			}
+		}
+		for c, cw := range classes {
+			nb.classes[c] += cw
+		}
+	}
+	return nb, nil
+}
+
+// predict returns the most probable class of an original row, with Laplace smoothing.
+func (nb *naiveBayes) predict(data []partition.Partition) int {
+	var total float64
+	for _, n := range nb.classes {
+		total += n
+	}
+	best, bestScore := 0, math.Inf(-1)
+	for c, n := range nb.classes {
+		score := math.Log((n + 1) / (total + float64(len(nb.classes))))
+		for f, e := range nb.features {
+			i := e.category(data[e.column])
+			score += math.Log((nb.counts[c][f][i] + 1) / (n + float64(e.size())))
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
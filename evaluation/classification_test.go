@@ -0,0 +1,97 @@
+package evaluation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+// getClassificationTable returns a table, in which the Risk column is determined by
+// the Age column, and the Gender column is noise. The age of each row is generalized
+// by the given function.
+func getClassificationTable(generalize func(age int) partition.Partition) *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Age", nil),
+			model.NewColumn("Gender", nil),
+			model.NewColumn("Risk", nil),
+		},
+	})
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		age := rnd.Intn(100)
+		risk := "low"
+		if age >= 50 {
+			risk = "high"
+		}
+		gender := []string{"male", "female"}[rnd.Intn(2)]
+		table.AppendRows(&model.Row{Data: []partition.Partition{
+			generalize(age), partition.NewItem(gender), partition.NewItem(risk),
+		}})
+	}
+	return table
+}
+
+func TestClassification_Evaluate(t *testing.T) {
+
+	original := getClassificationTable(func(age int) partition.Partition {
+		return partition.NewIntRange(age, age)
+	})
+
+	t.Run("mild generalization", func(t *testing.T) {
+		anonymized := getClassificationTable(func(age int) partition.Partition {
+			return partition.NewIntRange(age/10*10, age/10*10+9)
+		})
+		c := &Classification{Target: "Risk", Rand: rand.New(rand.NewSource(2))}
+		report, err := c.Evaluate(original, anonymized)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(60, report.Tests, t)
+		testutil.AssertEquals(1.0, report.OriginalAccuracy, t)
+		testutil.AssertEquals(1.0, report.AnonymizedAccuracy, t)
+		testutil.AssertEquals(0.0, report.Difference, t)
+	})
+
+	t.Run("full generalization", func(t *testing.T) {
+		anonymized := getClassificationTable(func(age int) partition.Partition {
+			return partition.NewIntRange(0, 99)
+		})
+		c := &Classification{Target: "Risk", Holdout: 0.5, Rand: rand.New(rand.NewSource(2))}
+		report, err := c.Evaluate(original, anonymized)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(100, report.Tests, t)
+		testutil.AssertEquals(1.0, report.OriginalAccuracy, t)
+		if report.Difference < 0.2 {
+			t.Errorf("expected a large accuracy loss, got %v", report.Difference)
+		}
+	})
+
+	t.Run("suppressed categorical feature", func(t *testing.T) {
+		anonymized := getClassificationTable(func(age int) partition.Partition {
+			return partition.NewIntRange(age, age)
+		})
+		for _, row := range anonymized.GetRows() {
+			row.Data[1] = partition.NewItem("*")
+		}
+		c := &Classification{Target: "Risk", Features: []string{"Gender"}, Rand: rand.New(rand.NewSource(2))}
+		report, err := c.Evaluate(original, anonymized)
+		testutil.AssertNil(err, t)
+		if report.OriginalAccuracy > 0.75 || report.AnonymizedAccuracy > 0.75 {
+			t.Errorf("expected no predictive power, got %v, %v", report.OriginalAccuracy, report.AnonymizedAccuracy)
+		}
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		c := &Classification{Target: "Income"}
+		_, err := c.Evaluate(original, original)
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("different row counts", func(t *testing.T) {
+		c := &Classification{Target: "Risk"}
+		_, err := c.Evaluate(original, model.NewTable(original.GetSchema()))
+		testutil.AssertNotNil(err, t)
+	})
+}
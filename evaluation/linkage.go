@@ -0,0 +1,167 @@
+package evaluation
+
+import (
+	"fmt"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// LinkageReport is the result of a simulated linkage attack.
+// Records is the number of records in the background table, Matched the number of records
+// which match at least one row of the release, and UniqueMatches the number of records which
+// match exactly one row. CorrectReidentifications is the number of unique matches, which link
+// the record to the row of the same individual. ExpectedSuccessRate is the probability that
+// the attacker re-identifies a record, when picking one of the matching rows at random.
+type LinkageReport struct {
+	Records                  int
+	Matched                  int
+	UniqueMatches            int
+	CorrectReidentifications int
+	ExpectedSuccessRate      float64
+}
+
+// Linkage simulates an attacker, who links the records of a background table to the rows of
+// an anonymized release. The background table holds some of the identifier columns of the
+// release with original values, and a record matches a row if each of its values is contained
+// by the partition of the column with the same name in the row. Suppressed values match any value.
+// The id column, present in both tables, tells which row belongs to the individual of a record.
+// If id is empty, the record at each index belongs to the row at the same index, which is the
+// case for tables anonymized in place by the Anonymizer.
+func Linkage(release, background *model.Table, id string) (*LinkageReport, error) {
+	columns, err := linkedColumns(release.GetSchema(), background.GetSchema(), id)
+	if err != nil {
+		return nil, err
+	}
+	truth, err := groundTruth(release, background, id)
+	if err != nil {
+		return nil, err
+	}
+	report := &LinkageReport{Records: len(background.GetRows())}
+	var success float64
+	for b, record := range background.GetRows() {
+		values := make([]interface{}, len(columns))
+		for i, c := range columns {
+			if values[i], err = originalValue(record.Data[c[1]]); err != nil {
+				return nil, fmt.Errorf("background record %d: %w", b, err)
+			}
+		}
+		var matches []int
+		for r, row := range release.GetRows() {
+			if matchRow(row, columns, values) {
+				matches = append(matches, r)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		report.Matched++
+		correct := false
+		for _, r := range matches {
+			if r == truth[b] {
+				correct = true
+			}
+		}
+		if len(matches) == 1 {
+			report.UniqueMatches++
+			if correct {
+				report.CorrectReidentifications++
+			}
+		}
+		if correct {
+			success += 1 / float64(len(matches))
+		}
+	}
+	if report.Records > 0 {
+		report.ExpectedSuccessRate = success / float64(report.Records)
+	}
+	return report, nil
+}
+
+// linkedColumns returns the index pairs (release, background) of the columns with the same
+// name in both tables, except for the id column.
+func linkedColumns(release, background *model.Schema, id string) ([][2]int, error) {
+	var columns [][2]int
+	for b, col := range background.Columns {
+		if col.GetName() == id {
+			continue
+		}
+		r := release.IndexOf(col.GetName())
+		if r < 0 {
+			return nil, fmt.Errorf("background column %s is missing from the release", col.GetName())
+		}
+		columns = append(columns, [2]int{r, b})
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to link on")
+	}
+	return columns, nil
+}
+
+// groundTruth returns the index of the row in the release for each record of the background
+// table, or -1 if the individual of the record is not in the release.
+func groundTruth(release, background *model.Table, id string) ([]int, error) {
+	truth := make([]int, len(background.GetRows()))
+	if id == "" {
+		for b := range truth {
+			truth[b] = b
+			if b >= len(release.GetRows()) {
+				truth[b] = -1
+			}
+		}
+		return truth, nil
+	}
+	r, b := release.GetSchema().IndexOf(id), background.GetSchema().IndexOf(id)
+	if r < 0 || b < 0 {
+		return nil, fmt.Errorf("id column %s is missing", id)
+	}
+	rows := make(map[string]int)
+	for i, row := range release.GetRows() {
+		rows[row.Data[r].String()] = i
+	}
+	for i, record := range background.GetRows() {
+		truth[i] = -1
+		if idx, ok := rows[record.Data[b].String()]; ok {
+			truth[i] = idx
+		}
+	}
+	return truth, nil
+}
+
+func matchRow(row *model.Row, columns [][2]int, values []interface{}) bool {
+	for i, c := range columns {
+		p := row.Data[c[0]]
+		if !isSuppressed(p) && !p.Contains(values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// originalValue returns the item of an original, not generalized partition.
+func originalValue(p partition.Partition) (interface{}, error) {
+	switch q := p.(type) {
+	case *partition.Item:
+		return q.GetItem(), nil
+	case *partition.IntRange:
+		if q.Min() == q.Max() {
+			return int(q.Min()), nil
+		}
+	case *partition.FloatRange:
+		if q.Min() == q.Max() {
+			return q.Min(), nil
+		}
+	case *partition.Set:
+		if len(q.Items) == 1 {
+			for item := range q.Items {
+				return item, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("not an original value: %v", p)
+}
+
+func isSuppressed(p partition.Partition) bool {
+	item, ok := p.(*partition.Item)
+	return ok && item.GetItem() == "*"
+}
@@ -0,0 +1,118 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getTestRelease() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("ID", nil),
+			model.NewColumn("Age", nil),
+			model.NewColumn("Zip", nil),
+			model.NewColumn("Disease", nil),
+		},
+	})
+	rows := []struct {
+		id      string
+		age     partition.Partition
+		zip     string
+		disease string
+	}{
+		{"a", partition.NewIntRange(20, 29), "*", "flu"},
+		{"b", partition.NewIntRange(20, 29), "*", "cancer"},
+		{"c", partition.NewIntRange(30, 39), "1234", "flu"},
+		{"d", partition.NewIntRange(30, 39), "1234", "hiv"},
+		{"e", partition.NewIntRange(40, 40), "5678", "flu"},
+	}
+	for _, r := range rows {
+		table.AppendRows(&model.Row{Data: []partition.Partition{
+			partition.NewItem(r.id), r.age, partition.NewItem(r.zip), partition.NewItem(r.disease),
+		}})
+	}
+	return table
+}
+
+func getTestBackground(records ...[]interface{}) *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("ID", nil),
+			model.NewColumn("Age", nil),
+			model.NewColumn("Zip", nil),
+		},
+	})
+	for _, r := range records {
+		table.AppendRows(&model.Row{Data: []partition.Partition{
+			partition.NewItem(r[0]), partition.NewIntRange(r[1].(int), r[1].(int)), partition.NewItem(r[2]),
+		}})
+	}
+	return table
+}
+
+func TestLinkage(t *testing.T) {
+
+	t.Run("linkage by id", func(t *testing.T) {
+		background := getTestBackground(
+			[]interface{}{"a", 25, "9999"}, // matches a and b (suppressed zip)
+			[]interface{}{"c", 33, "1234"}, // matches c and d
+			[]interface{}{"e", 40, "5678"}, // unique match
+			[]interface{}{"x", 50, "1234"}, // no match
+		)
+		report, err := Linkage(getTestRelease(), background, "ID")
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(4, report.Records, t)
+		testutil.AssertEquals(3, report.Matched, t)
+		testutil.AssertEquals(1, report.UniqueMatches, t)
+		testutil.AssertEquals(1, report.CorrectReidentifications, t)
+		testutil.AssertEquals((0.5+0.5+1)/4, report.ExpectedSuccessRate, t)
+	})
+
+	t.Run("wrong unique match", func(t *testing.T) {
+		background := getTestBackground([]interface{}{"d", 40, "5678"})
+		report, err := Linkage(getTestRelease(), background, "ID")
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(1, report.UniqueMatches, t)
+		testutil.AssertEquals(0, report.CorrectReidentifications, t)
+		testutil.AssertEquals(0.0, report.ExpectedSuccessRate, t)
+	})
+
+	t.Run("linkage by row order", func(t *testing.T) {
+		background := model.NewTable(&model.Schema{
+			Columns: []*model.Column{model.NewColumn("Age", nil)},
+		})
+		for _, age := range []int{25, 26, 31, 32, 40} {
+			background.AppendRows(&model.Row{Data: []partition.Partition{partition.NewIntRange(age, age)}})
+		}
+		report, err := Linkage(getTestRelease(), background, "")
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(5, report.Matched, t)
+		testutil.AssertEquals(1, report.CorrectReidentifications, t)
+		testutil.AssertEquals((4*0.5+1)/5, report.ExpectedSuccessRate, t)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		background := model.NewTable(&model.Schema{
+			Columns: []*model.Column{model.NewColumn("Gender", nil)},
+		})
+		_, err := Linkage(getTestRelease(), background, "")
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("missing id column", func(t *testing.T) {
+		_, err := Linkage(getTestRelease(), getTestBackground(), "SSN")
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("generalized background", func(t *testing.T) {
+		background := getTestBackground()
+		background.AppendRows(&model.Row{Data: []partition.Partition{
+			partition.NewItem("a"), partition.NewIntRange(20, 29), partition.NewItem("1234"),
+		}})
+		_, err := Linkage(getTestRelease(), background, "ID")
+		testutil.AssertNotNil(err, t)
+	})
+}
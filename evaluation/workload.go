@@ -0,0 +1,255 @@
+package evaluation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Condition restricts the values of a column, either to the range [Min, Max] of numeric
+// values, or to a set of Items. Conditions are created with Between and In.
+type Condition struct {
+	Column string
+	Min    float64
+	Max    float64
+	Items  []interface{}
+}
+
+// Between creates a condition for the numeric values of a column between min and max, inclusive.
+// Use math.Inf for open ranges.
+func Between(column string, min, max float64) *Condition {
+	return &Condition{Column: column, Min: min, Max: max}
+}
+
+// In creates a condition for the values of a column, which are one of the given items.
+// At least one item must be given, Workload returns an error for a condition without items.
+// Repeated items are only kept once.
+func In(column string, items ...interface{}) *Condition {
+	c := &Condition{Column: column, Items: []interface{}{}}
+	for _, item := range items {
+		if !c.match(item) {
+			c.Items = append(c.Items, item)
+		}
+	}
+	return c
+}
+
+// Query is a count query for the rows, which satisfy all conditions.
+type Query struct {
+	Name       string
+	Conditions []*Condition
+}
+
+// QueryResult contains the answer of a query on the original table, the answer estimated
+// from the anonymized table, and the relative error of the estimate.
+type QueryResult struct {
+	Query         *Query
+	Actual        float64
+	Estimated     float64
+	RelativeError float64
+}
+
+// WorkloadReport contains the result of each query of a workload, and the mean, median and
+// maximum of the relative errors.
+type WorkloadReport struct {
+	Results             []*QueryResult
+	MeanRelativeError   float64
+	MedianRelativeError float64
+	MaxRelativeError    float64
+}
+
+// Workload runs the queries against the original and the anonymized table, and reports the
+// relative error of the answers on the anonymized table. Columns are referred to by name.
+// Generalized values are assumed to be spread uniformly: a row with a range partition counts
+// with the part of the range, and a row with a set partition (such as a hierarchy node) with
+// the part of the set, which satisfies the condition. Suppressed values are spread uniformly
+// over the distinct values of the column in the original table.
+// The relative error is |estimated - actual| / max(actual, 1), so queries with no matching
+// rows do not dominate the aggregates.
+func Workload(original, anonymized *model.Table, queries []*Query) (*WorkloadReport, error) {
+	report := &WorkloadReport{}
+	domains := make(map[string][]partition.Partition)
+	var relative []float64
+	for _, q := range queries {
+		if err := collectDomains(original, q, domains); err != nil {
+			return nil, err
+		}
+		actual, err := estimate(original, q, domains)
+		if err != nil {
+			return nil, fmt.Errorf("query %s on the original table: %w", q.Name, err)
+		}
+		estimated, err := estimate(anonymized, q, domains)
+		if err != nil {
+			return nil, fmt.Errorf("query %s on the anonymized table: %w", q.Name, err)
+		}
+		result := &QueryResult{
+			Query:         q,
+			Actual:        actual,
+			Estimated:     estimated,
+			RelativeError: math.Abs(estimated-actual) / math.Max(actual, 1),
+		}
+		report.Results = append(report.Results, result)
+		relative = append(relative, result.RelativeError)
+	}
+	if len(relative) == 0 {
+		return report, nil
+	}
+	sort.Float64s(relative)
+	for _, e := range relative {
+		report.MeanRelativeError += e
+	}
+	report.MeanRelativeError /= float64(len(relative))
+	report.MaxRelativeError = relative[len(relative)-1]
+	if n := len(relative); n%2 == 1 {
+		report.MedianRelativeError = relative[n/2]
+	} else {
+		report.MedianRelativeError = (relative[n/2-1] + relative[n/2]) / 2
+	}
+	return report, nil
+}
+
+// collectDomains validates the conditions of the query, and collects the distinct values of
+// their columns in the original table.
+func collectDomains(original *model.Table, q *Query, domains map[string][]partition.Partition) error {
+	for _, c := range q.Conditions {
+		if c.Items != nil && len(c.Items) == 0 {
+			return fmt.Errorf("query %s: no items in condition for column %s", q.Name, c.Column)
+		}
+		if _, ok := domains[c.Column]; ok {
+			continue
+		}
+		colIdx := original.GetSchema().IndexOf(c.Column)
+		if colIdx < 0 {
+			return fmt.Errorf("unknown column: %s", c.Column)
+		}
+		seen := make(map[string]bool)
+		var values []partition.Partition
+		for _, row := range original.GetRows() {
+			p := row.Data[colIdx]
+			if key := p.String(); !seen[key] && !isSuppressed(p) {
+				seen[key] = true
+				values = append(values, p)
+			}
+		}
+		domains[c.Column] = values
+	}
+	return nil
+}
+
+// estimate returns the expected number of rows of the table, which satisfy the query.
+func estimate(table *model.Table, q *Query, domains map[string][]partition.Partition) (float64, error) {
+	columns := make([]int, len(q.Conditions))
+	for i, c := range q.Conditions {
+		columns[i] = table.GetSchema().IndexOf(c.Column)
+		if columns[i] < 0 {
+			return 0, fmt.Errorf("unknown column: %s", c.Column)
+		}
+	}
+	var count float64
+	for _, row := range table.GetRows() {
+		p := 1.0
+		for i, c := range q.Conditions {
+			f, err := c.fraction(row.Data[columns[i]], domains[c.Column])
+			if err != nil {
+				return 0, err
+			}
+			p *= f
+		}
+		count += p
+	}
+	return count, nil
+}
+
+// fraction returns the part of the partition, which satisfies the condition.
+// Suppressed partitions take on each value of the domain with equal probability.
+func (c *Condition) fraction(p partition.Partition, domain []partition.Partition) (float64, error) {
+	switch q := p.(type) {
+	case *partition.Item:
+		if !isSuppressed(q) {
+			return indicator(c.match(q.GetItem())), nil
+		}
+		if len(domain) == 0 {
+			return 0, nil
+		}
+		var f float64
+		for _, d := range domain {
+			v, err := c.fraction(d, nil)
+			if err != nil {
+				return 0, err
+			}
+			f += v
+		}
+		return f / float64(len(domain)), nil
+	case *partition.Set:
+		n := 0
+		for item := range q.Items {
+			if c.match(item) {
+				n++
+			}
+		}
+		return float64(n) / float64(len(q.Items)), nil
+	case *partition.IntRange:
+		return c.intFraction(int(q.Min()), int(q.Max())), nil
+	case *partition.FloatRange:
+		return c.floatFraction(q.Min(), q.Max()), nil
+	}
+	return 0, fmt.Errorf("unsupported partition: %v", p)
+}
+
+func (c *Condition) intFraction(min, max int) float64 {
+	if c.Items != nil {
+		n := 0
+		for _, item := range c.Items {
+			if i, ok := item.(int); ok && min <= i && i <= max {
+				n++
+			}
+		}
+		return float64(n) / float64(max-min+1)
+	}
+	lo := math.Max(float64(min), math.Ceil(c.Min))
+	hi := math.Min(float64(max), math.Floor(c.Max))
+	return math.Max(0, hi-lo+1) / float64(max-min+1)
+}
+
+func (c *Condition) floatFraction(min, max float64) float64 {
+	if min == max {
+		return indicator(c.match(min))
+	}
+	if c.Items != nil {
+		return 0
+	}
+	lo, hi := math.Max(min, c.Min), math.Min(max, c.Max)
+	return math.Max(0, hi-lo) / (max - min)
+}
+
+// match returns true if an item satisfies the condition.
+func (c *Condition) match(item interface{}) bool {
+	if c.Items != nil {
+		for _, i := range c.Items {
+			if i == item {
+				return true
+			}
+		}
+		return false
+	}
+	var v float64
+	switch i := item.(type) {
+	case int:
+		v = float64(i)
+	case float64:
+		v = i
+	default:
+		return false
+	}
+	return c.Min <= v && v <= c.Max
+}
+
+func indicator(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,121 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getWorkloadTables() (*model.Table, *model.Table) {
+	schema := &model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Age", nil),
+			model.NewColumn("Income", nil),
+			model.NewColumn("City", nil),
+		},
+	}
+	original, anonymized := model.NewTable(schema), model.NewTable(schema)
+	rows := []struct {
+		age, income     int
+		city            string
+		ageRange        partition.Partition
+		incomeRange     partition.Partition
+		generalizedCity partition.Partition
+	}{
+		{24, 10000, "Budapest", partition.NewIntRange(20, 29), partition.NewFloatRange(10000, 20000), partition.NewSet("Budapest", "Szeged")},
+		{26, 15000, "Szeged", partition.NewIntRange(20, 29), partition.NewFloatRange(10000, 20000), partition.NewSet("Budapest", "Szeged")},
+		{35, 30000, "Debrecen", partition.NewIntRange(30, 39), partition.NewFloatRange(30000, 30000), partition.NewItem("*")},
+		{38, 30000, "Budapest", partition.NewIntRange(30, 39), partition.NewFloatRange(30000, 30000), partition.NewItem("*")},
+	}
+	for _, r := range rows {
+		original.AppendRows(&model.Row{Data: []partition.Partition{
+			partition.NewIntRange(r.age, r.age), partition.NewItem(float64(r.income)), partition.NewItem(r.city),
+		}})
+		anonymized.AppendRows(&model.Row{Data: []partition.Partition{
+			r.ageRange, r.incomeRange, r.generalizedCity,
+		}})
+	}
+	return original, anonymized
+}
+
+func TestWorkload(t *testing.T) {
+
+	t.Run("estimates", func(t *testing.T) {
+		original, anonymized := getWorkloadTables()
+		queries := []*Query{
+			{Name: "age 25-30", Conditions: []*Condition{Between("Age", 25, 30)}},
+			{Name: "income < 15000", Conditions: []*Condition{Between("Income", math.Inf(-1), 14999)}},
+			{Name: "budapest", Conditions: []*Condition{In("City", "Budapest")}},
+			{Name: "older in szeged", Conditions: []*Condition{Between("Age", 30, 100), In("City", "Szeged")}},
+		}
+		report, err := Workload(original, anonymized, queries)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(4, len(report.Results), t)
+
+		assertResult(t, report.Results[0], 1, 2*5.0/10+2*1.0/10)
+		assertResult(t, report.Results[1], 1, 2*4999.0/10000)
+		assertResult(t, report.Results[2], 2, 0.5+0.5+2.0/3)
+		assertResult(t, report.Results[3], 0, 2.0/3)
+
+		testutil.AssertEquals(report.Results[3].RelativeError, report.MaxRelativeError, t)
+		var mean float64
+		for _, r := range report.Results {
+			mean += r.RelativeError
+		}
+		assertClose(t, mean/4, report.MeanRelativeError)
+	})
+
+	t.Run("identical tables", func(t *testing.T) {
+		original, _ := getWorkloadTables()
+		queries := []*Query{{Conditions: []*Condition{Between("Age", 20, 30), In("City", "Budapest", "Szeged")}}}
+		report, err := Workload(original, original, queries)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(2.0, report.Results[0].Actual, t)
+		testutil.AssertEquals(0.0, report.MaxRelativeError, t)
+	})
+
+	t.Run("repeated items", func(t *testing.T) {
+		original, anonymized := getWorkloadTables()
+		c := In("Age", 24, 24, 35)
+		testutil.AssertEquals(2, len(c.Items), t)
+		report, err := Workload(original, anonymized, []*Query{{Conditions: []*Condition{c}}})
+		testutil.AssertNil(err, t)
+		assertResult(t, report.Results[0], 2, 2*1.0/10+2*1.0/10)
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		original, anonymized := getWorkloadTables()
+		_, err := Workload(original, anonymized, []*Query{{Conditions: []*Condition{In("Zip", 1234)}}})
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("in without items", func(t *testing.T) {
+		original, anonymized := getWorkloadTables()
+		_, err := Workload(original, anonymized, []*Query{{Conditions: []*Condition{In("City")}}})
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("empty workload", func(t *testing.T) {
+		original, anonymized := getWorkloadTables()
+		report, err := Workload(original, anonymized, nil)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(0.0, report.MeanRelativeError, t)
+	})
+}
+
+func assertResult(t *testing.T, result *QueryResult, actual, estimated float64) {
+	t.Helper()
+	assertClose(t, actual, result.Actual)
+	assertClose(t, estimated, result.Estimated)
+	assertClose(t, math.Abs(estimated-actual)/math.Max(actual, 1), result.RelativeError)
+}
+
+func assertClose(t *testing.T, expected, actual float64) {
+	t.Helper()
+	if math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
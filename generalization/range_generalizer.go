@@ -42,6 +42,11 @@ func (g *RangeGeneralizer) Generalize(p partition.Partition, n int) partition.Pa
 	return path[n]
 }
 
+// Range returns the range covering the whole domain of the generalizer.
+func (g *RangeGeneralizer) Range() partition.Range {
+	return g.r
+}
+
 // Levels returns the number of levels in the hierarchy.
 func (g *RangeGeneralizer) Levels() int {
 	return g.r.MaxSplit() + 1
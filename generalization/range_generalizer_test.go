@@ -206,6 +206,11 @@ func TestRangeGeneralizer_Generalize(t *testing.T) {
 
 }
 
+func TestRangeGeneralizer_Range(t *testing.T) {
+	gen := NewIntRangeGeneralizer(5, 10)
+	testutil.AssertEquals(true, partition.NewIntRange(5, 10).Equals(gen.Range()), t)
+}
+
 func TestRangeGeneralizer_Levels(t *testing.T) {
 
 	t.Run("returns max split + 1", func(t *testing.T) {
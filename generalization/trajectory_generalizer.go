@@ -0,0 +1,64 @@
+package generalization
+
+import "github.com/gar-r/k-anon/partition"
+
+// TrajectoryGeneralizer generalizes trajectories point by point, using one generalizer
+// for the places and another one for the times of the points.
+type TrajectoryGeneralizer struct {
+	Place Generalizer
+	Time  Generalizer
+}
+
+// Visit is a raw point of a trajectory, the place and the time are initialized
+// with the place and time generalizers of the TrajectoryGeneralizer.
+type Visit struct {
+	Place interface{}
+	Time  interface{}
+}
+
+// NewTrajectoryGeneralizer creates a new TrajectoryGeneralizer with the given place and time generalizers.
+func NewTrajectoryGeneralizer(place, time Generalizer) *TrajectoryGeneralizer {
+	return &TrajectoryGeneralizer{Place: place, Time: time}
+}
+
+// Generalize generalizes the place and the time of each point of the trajectory to level n
+// of the place and time generalizers. When one of the generalizers has less levels, its values
+// are generalized to its highest level instead.
+// This function returns nil, when the partition is not a trajectory, or it cannot be generalized to level n.
+func (g *TrajectoryGeneralizer) Generalize(p partition.Partition, n int) partition.Partition {
+	t, success := p.(*partition.Trajectory)
+	if !success || n < 0 || n >= g.Levels() {
+		return nil
+	}
+	points := make([]partition.Point, len(t.Points))
+	for i, point := range t.Points {
+		place := g.Place.Generalize(point.Place, min(n, g.Place.Levels()-1))
+		time := g.Time.Generalize(point.Time, min(n, g.Time.Levels()-1))
+		if place == nil || time == nil {
+			return nil
+		}
+		points[i] = partition.Point{Place: place, Time: time}
+	}
+	return partition.NewTrajectory(points...)
+}
+
+// Levels returns the number of levels of the place or the time generalizer, whichever is more.
+func (g *TrajectoryGeneralizer) Levels() int {
+	return max(g.Place.Levels(), g.Time.Levels())
+}
+
+// InitItem initializes a trajectory from a slice of visits. Trajectories are returned as they are,
+// other items result in an empty trajectory.
+func (g *TrajectoryGeneralizer) InitItem(item interface{}) partition.Partition {
+	switch q := item.(type) {
+	case *partition.Trajectory:
+		return q
+	case []Visit:
+		points := make([]partition.Point, len(q))
+		for i, v := range q {
+			points[i] = partition.Point{Place: g.Place.InitItem(v.Place), Time: g.Time.InitItem(v.Time)}
+		}
+		return partition.NewTrajectory(points...)
+	}
+	return partition.NewTrajectory()
+}
@@ -0,0 +1,79 @@
+package generalization
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getTestTrajectoryGeneralizer() *TrajectoryGeneralizer {
+	return NewTrajectoryGeneralizer(ExampleGradeGeneralizer(), NewIntRangeGeneralizer(0, 23))
+}
+
+func TestTrajectoryGeneralizer_Levels(t *testing.T) {
+	g := getTestTrajectoryGeneralizer()
+	testutil.AssertEquals(g.Time.Levels(), g.Levels(), t)
+}
+
+func TestTrajectoryGeneralizer_InitItem(t *testing.T) {
+	g := getTestTrajectoryGeneralizer()
+
+	t.Run("visits", func(t *testing.T) {
+		actual := g.InitItem([]Visit{{Place: "A", Time: 8}, {Place: "B+", Time: 10}})
+		expected := partition.NewTrajectory(
+			partition.Point{Place: partition.NewSet("A"), Time: partition.NewIntRange(8, 8)},
+			partition.Point{Place: partition.NewSet("B+"), Time: partition.NewIntRange(10, 10)},
+		)
+		if !expected.Equals(actual) {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+	})
+
+	t.Run("trajectory", func(t *testing.T) {
+		tr := partition.NewTrajectory()
+		testutil.AssertEquals(partition.Partition(tr), g.InitItem(tr), t)
+	})
+
+	t.Run("other item", func(t *testing.T) {
+		testutil.AssertEquals("<>", g.InitItem("A").String(), t)
+	})
+}
+
+func TestTrajectoryGeneralizer_Generalize(t *testing.T) {
+	g := getTestTrajectoryGeneralizer()
+	tr := g.InitItem([]Visit{{Place: "A", Time: 8}, {Place: "C-", Time: 20}})
+
+	t.Run("level 0", func(t *testing.T) {
+		actual := g.Generalize(tr, 0)
+		if !tr.Equals(actual) {
+			t.Errorf("expected %v, got %v", tr, actual)
+		}
+	})
+
+	t.Run("level 1", func(t *testing.T) {
+		actual := g.Generalize(tr, 1)
+		expected := partition.NewTrajectory(
+			partition.Point{Place: partition.NewSet("A+", "A", "A-"), Time: g.Time.Generalize(partition.NewIntRange(8, 8), 1)},
+			partition.Point{Place: partition.NewSet("C+", "C", "C-"), Time: g.Time.Generalize(partition.NewIntRange(20, 20), 1)},
+		)
+		if !expected.Equals(actual) {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+	})
+
+	t.Run("place at its highest level", func(t *testing.T) {
+		actual := g.Generalize(tr, 3).(*partition.Trajectory)
+		testutil.AssertEquals(true, actual.Points[0].Place.Equals(actual.Points[1].Place), t)
+		testutil.AssertEquals(false, actual.Points[0].Time.Equals(actual.Points[1].Time), t)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		testutil.AssertNil(g.Generalize(tr, g.Levels()), t)
+		testutil.AssertNil(g.Generalize(tr, -1), t)
+	})
+
+	t.Run("not a trajectory", func(t *testing.T) {
+		testutil.AssertNil(g.Generalize(partition.NewSet("A"), 0), t)
+	})
+}
@@ -0,0 +1,266 @@
+package kanon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// runState is the result of the anonymization, which is kept for Save, and for the next run
+// of an incremental anonymizer. Each run records its groups and its anonymization forest,
+// the cost graph, the pending rows and the original data are only kept in Incremental mode.
+// The nodes of the graphs are the indices of the rows, the Steiner's vertices of the forest
+// are numbered from -1 downwards.
+type runState struct {
+	published int                             // number of rows processed by the previous runs
+	groups    [][]int                         // row indices of the published groups
+	pending   []int                           // rows without a group, released suppressed
+	costs     *simple.WeightedUndirectedGraph // cost graph between the original rows
+	forest    *simple.UndirectedGraph         // anonymization forest of the groups
+	steiner   int                             // number of Steiner's vertices in the forest
+	originals [][]partition.Partition         // data of the processed rows before the anonymization
+}
+
+func newRunState() *runState {
+	return &runState{
+		costs:  simple.NewWeightedUndirectedGraph(0, math.MaxFloat64),
+		forest: simple.NewUndirectedGraph(),
+	}
+}
+
+// placement assigns a row to an existing group, with the partitions the row
+// takes on in the identifier columns, and its parent in the forest.
+type placement struct {
+	row    int
+	group  int
+	parent int
+	data   map[int]partition.Partition
+}
+
+// Pending returns the indices of the rows, which are waiting for a group in Incremental mode.
+// Pending rows are released with '*' in the identifier columns, until they can join a group,
+// or K-1 other rows arrive, which they can form a new group with.
+func (a *Anonymizer) Pending() []int {
+	if a.state == nil {
+		return nil
+	}
+	return a.state.pending
+}
+
+// anonymizeIncremental anonymizes the pending rows and the rows added since the previous run:
+//   - the cost graph is extended with the costs of the new rows
+//   - rows which generalize into the partitions of an existing group join the group with
+//     the cheapest row, and are connected to that row in the forest
+//   - the remaining rows are divided into blocks (see blocks), and new groups are formed
+//     in the blocks of at least K rows with the graph algorithm
+//   - the rows of smaller blocks stay pending
+//
+// Published groups are never generalized again, so the rows are never released with
+// partitions, which contradict an earlier release.
+func (a *Anonymizer) anonymizeIncremental(ctx context.Context) error {
+	if a.Neighbours > 0 {
+		return errors.New("the sparse cost graph is not supported in incremental mode")
+	}
+	rows := a.Table.GetRows()
+	if len(rows) < a.state.published {
+		return fmt.Errorf("table has %d rows, but %d rows were already published", len(rows), a.state.published)
+	}
+	added := a.copyRows(a.state.published)
+	originals := a.originalTable()
+	if err := algorithm.ExtendCostGraphContext(ctx, a.state.costs, originals, a.options()); err != nil {
+		return err
+	}
+	var placements []*placement
+	var pending []int
+	candidates := append([]int(nil), a.state.pending...)
+	for i := a.state.published; i < len(rows); i++ {
+		candidates = append(candidates, i)
+	}
+	for _, row := range candidates {
+		if p := a.place(row, originals.GetRows()[row]); p != nil {
+			placements = append(placements, p)
+		} else {
+			pending = append(pending, row)
+		}
+	}
+	var blocks, small [][]int
+	if len(pending) > 0 {
+		view := &Anonymizer{K: a.K, Table: originals}
+		blocks, small = view.blocks(pending)
+	}
+	groups, forests, err := a.groupRows(ctx, blocks)
+	if err != nil {
+		return err
+	}
+	for _, p := range placements {
+		for colIdx, data := range p.data {
+			rows[p.row].Data[colIdx] = data
+		}
+		a.state.groups[p.group] = append(a.state.groups[p.group], p.row)
+		a.state.forest.SetEdge(a.state.forest.NewEdge(simple.Node(p.row), simple.Node(p.parent)))
+	}
+	for i, block := range blocks {
+		a.state.addForest(block, forests[i])
+	}
+	a.restore(originals, flatten(groups))
+	a.generalize(groups)
+	a.state.groups = append(a.state.groups, groups...)
+	a.state.pending = flatten(small)
+	sort.Ints(a.state.pending)
+	a.suppressIdentifiers(a.state.pending)
+	a.state.originals = append(a.state.originals, added...)
+	a.state.published = len(rows)
+	return nil
+}
+
+// originalTable returns a Table with the original data of the processed rows, followed by the new rows.
+func (a *Anonymizer) originalTable() *model.Table {
+	table := model.NewTable(a.Table.GetSchema())
+	for _, data := range a.state.originals {
+		table.AppendRows(&model.Row{Data: data})
+	}
+	table.AppendRows(a.Table.GetRows()[a.state.published:]...)
+	return table
+}
+
+// restore writes the original data of the identifier columns of the rows back to the Table.
+func (a *Anonymizer) restore(originals *model.Table, rows []int) {
+	for _, rowIdx := range rows {
+		for colIdx, col := range a.Table.GetSchema().Columns {
+			if col.IsIdentifier() {
+				a.Table.GetRows()[rowIdx].Data[colIdx] = originals.GetRows()[rowIdx].Data[colIdx]
+			}
+		}
+	}
+}
+
+// copyRows returns a copy of the data of the rows starting from the given index.
+func (a *Anonymizer) copyRows(from int) [][]partition.Partition {
+	rows := a.Table.GetRows()[from:]
+	result := make([][]partition.Partition, len(rows))
+	for i, row := range rows {
+		result[i] = append([]partition.Partition(nil), row.Data...)
+	}
+	return result
+}
+
+// place finds the group, which the row can join without changing the partitions of the group,
+// and which has the cheapest row to connect the row to in the cost graph.
+// Returns nil if there is no such group.
+func (a *Anonymizer) place(row int, data *model.Row) *placement {
+	var best *placement
+	bestCost := math.Inf(1)
+	for groupIdx, group := range a.state.groups {
+		fitted, ok := a.fit(data, a.Table.GetRows()[group[0]])
+		if !ok {
+			continue
+		}
+		for _, member := range group {
+			cost, _ := a.state.costs.Weight(int64(row), int64(member))
+			if best == nil || cost < bestCost {
+				bestCost = cost
+				best = &placement{row: row, group: groupIdx, parent: member, data: fitted}
+			}
+		}
+	}
+	return best
+}
+
+// fit checks if each identifier column of the row can be generalized to the
+// partition of the same column in the representative row of a group.
+func (a *Anonymizer) fit(row, rep *model.Row) (map[int]partition.Partition, bool) {
+	data := make(map[int]partition.Partition)
+	for colIdx, colDef := range a.Table.GetSchema().Columns {
+		if !colDef.IsIdentifier() {
+			continue
+		}
+		g := colDef.GetGeneralizer()
+		for level := 0; level < g.Levels(); level++ {
+			p := g.Generalize(row.Data[colIdx], level)
+			if p == nil {
+				break
+			}
+			if p.Equals(rep.Data[colIdx]) {
+				data[colIdx] = p
+				break
+			}
+		}
+		if _, ok := data[colIdx]; !ok {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+// groupRows forms groups among the rows of each block with the graph algorithm, using the
+// costs of the kept cost graph. It returns the groups, and the decomposed forest of each block.
+func (a *Anonymizer) groupRows(ctx context.Context, blocks [][]int) ([][]int, []*simple.UndirectedGraph, error) {
+	opts := a.options()
+	var groups [][]int
+	var forests []*simple.UndirectedGraph
+	for _, rows := range blocks {
+		g, err := algorithm.BuildAnonGraphFromCostsContext(ctx, a.state.blockCosts(rows), a.K, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		undirected := algorithm.UndirectGraph(g)
+		d := algorithm.NewDecomposerWithOptions(undirected, a.K, opts)
+		if err := d.DecomposeContext(ctx); err != nil {
+			return nil, nil, err
+		}
+		groups = append(groups, getRowGroups(algorithm.ConnectedComponents(undirected), rows)...)
+		forests = append(forests, undirected)
+	}
+	return groups, forests, nil
+}
+
+// blockCosts returns the costs between the rows of a block, with the rows numbered from 0.
+func (s *runState) blockCosts(rows []int) *simple.WeightedUndirectedGraph {
+	g := simple.NewWeightedUndirectedGraph(0, math.MaxFloat64)
+	for i := range rows {
+		g.AddNode(simple.Node(i))
+	}
+	for i, u := range rows {
+		for j := i + 1; j < len(rows); j++ {
+			w, _ := s.costs.Weight(int64(u), int64(rows[j]))
+			g.SetWeightedEdge(g.NewWeightedEdge(simple.Node(i), simple.Node(j), w))
+		}
+	}
+	return g
+}
+
+// addForest adds the decomposed forest of a block of rows to the forest of the state.
+// Isolated Steiner's vertices are left out.
+func (s *runState) addForest(rows []int, g *simple.UndirectedGraph) {
+	nodes := graph.NodesOf(g.Nodes())
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID() < nodes[j].ID()
+	})
+	ids := make(map[int64]int64, len(nodes))
+	for _, n := range nodes {
+		if n.ID() < int64(len(rows)) {
+			ids[n.ID()] = int64(rows[n.ID()])
+		} else if g.From(n.ID()).Len() > 0 {
+			s.steiner++
+			ids[n.ID()] = -int64(s.steiner)
+		} else {
+			continue
+		}
+		if s.forest.Node(ids[n.ID()]) == nil {
+			s.forest.AddNode(simple.Node(ids[n.ID()]))
+		}
+	}
+	edges := g.Edges()
+	for edges.Next() {
+		e := edges.Edge()
+		s.forest.SetEdge(s.forest.NewEdge(simple.Node(ids[e.From().ID()]), simple.Node(ids[e.To().ID()])))
+	}
+}
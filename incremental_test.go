@@ -0,0 +1,135 @@
+package kanon
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestAnonymizer_Incremental(t *testing.T) {
+
+	t.Run("new rows join existing groups", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: makeCols(2, generalization.NewIntRangeGeneralizer(0, 100)),
+		})
+		table.AddRow(10, 10)
+		table.AddRow(12, 11)
+		table.AddRow(80, 90)
+		table.AddRow(82, 91)
+		anon := &Anonymizer{Table: table, K: 2, Incremental: true}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		published := snapshot(table)
+		table.AddRow(11, 10)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(2, len(anon.state.groups), t)
+		testutil.AssertEquals(true, anon.state.forest.HasEdgeBetween(4, 0) || anon.state.forest.HasEdgeBetween(4, 1), t)
+		assertUnchanged(t, table, published)
+		assertKAnonymity(table, 2, t)
+	})
+
+	t.Run("remaining rows are pending until K rows are available", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: makeCols(2, generalization.NewIntRangeGeneralizer(0, 100)),
+		})
+		table.AddRow(10, 10)
+		table.AddRow(12, 11)
+		table.AddRow(80, 90)
+		table.AddRow(82, 91)
+		anon := &Anonymizer{Table: table, K: 2, Incremental: true}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		published := snapshot(table)
+		table.AddRow(30, 20)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(2, len(anon.state.groups), t)
+		testutil.AssertEquals("[4]", fmt.Sprint(anon.Pending()), t)
+		testutil.AssertEquals("*", table.GetRows()[4].Data[0].String(), t)
+		testutil.AssertEquals("*", table.GetRows()[4].Data[1].String(), t)
+		assertUnchanged(t, table, published)
+
+		table.AddRow(32, 22)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(3, len(anon.state.groups), t)
+		testutil.AssertEquals("[]", fmt.Sprint(anon.Pending()), t)
+		testutil.AssertEquals(table.GetRows()[5].Data[0].String(), table.GetRows()[4].Data[0].String(), t)
+		assertUnchanged(t, table, published)
+		assertKAnonymity(table, 2, t)
+	})
+
+	t.Run("published groups are never generalized again", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(7))
+		table := model.NewTable(&model.Schema{
+			Columns: makeCols(2, generalization.NewIntRangeGeneralizer(0, 100)),
+		})
+		addRows := func(n int) {
+			for i := 0; i < n; i++ {
+				table.AddRow(rnd.Intn(100), rnd.Intn(100))
+			}
+		}
+		anon := &Anonymizer{
+			Table:       table,
+			K:           4,
+			Incremental: true,
+			Rand:        rand.New(rand.NewSource(7)),
+		}
+		for _, n := range []int{20, 2, 10, 1, 15} {
+			var grouped []int
+			if anon.state != nil {
+				grouped = flatten(anon.state.groups)
+			}
+			published := snapshot(table.Select(grouped))
+			addRows(n)
+			if err := anon.Anonymize(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertUnchanged(t, table.Select(grouped), published)
+			testutil.AssertEquals(len(table.GetRows()), anon.state.published, t)
+			testutil.AssertEquals(len(table.GetRows()), len(flatten(anon.state.groups))+len(anon.Pending()), t)
+			if len(anon.Pending()) >= 4 {
+				t.Errorf("%d rows are pending", len(anon.Pending()))
+			}
+			assertKAnonymity(table.Select(flatten(anon.state.groups)), 4, t)
+		}
+	})
+
+	t.Run("sparse cost graph", func(t *testing.T) {
+		anon := &Anonymizer{Table: model.GetStudentTable(), K: 2, Incremental: true, Neighbours: 5}
+		if err := anon.Anonymize(); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+// assertUnchanged checks that the first rows of the table have the given data.
+func assertUnchanged(t *testing.T, table *model.Table, data [][]partition.Partition) {
+	t.Helper()
+	for i, row := range data {
+		for colIdx, p := range row {
+			if !p.Equals(table.GetRows()[i].Data[colIdx]) {
+				t.Errorf("published partition changed from %v to %v", p, table.GetRows()[i].Data[colIdx])
+			}
+		}
+	}
+}
+
+func snapshot(table *model.Table) [][]partition.Partition {
+	result := make([][]partition.Partition, len(table.GetRows()))
+	for i, row := range table.GetRows() {
+		result[i] = append([]partition.Partition(nil), row.Data...)
+	}
+	return result
+}
@@ -0,0 +1,414 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/observer"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// republisher keeps the release history of an Anonymizer publishing in an m-invariant way.
+type republisher struct {
+	M         int
+	ID        string
+	Sensitive string
+	Observer  observer.Observer
+	Cost      algorithm.CostFunction
+
+	history    []*Release
+	signatures map[string][]string            // signature of each individual ever published
+	values     map[string]partition.Partition // sensitive values ever published
+}
+
+// Release is a published version of a table.
+// Groups contains the row indices of each group of Table, and Counterfeits the
+// number of counterfeit rows in the group with the same index.
+type Release struct {
+	Version      int
+	Table        *model.Table
+	Groups       [][]int
+	Counterfeits []int
+}
+
+// tuple is a row of the table being published.
+type tuple struct {
+	row         *model.Row
+	id          string
+	value       string
+	counterfeit bool
+}
+
+// bucket contains tuples with the same signature, and the same number of tuples for each
+// value of the signature, so it can be split into groups with one tuple for each value.
+type bucket struct {
+	signature []string
+	tuples    map[string][]*tuple
+}
+
+// Publish publishes the current version of the Table in an m-invariant way (Xiao & Tao:
+// "m-Invariance: Towards Privacy Preserving Re-publication of Dynamic Datasets"), and
+// records the release in the history of the anonymizer. Between the calls rows may be
+// inserted into and deleted from the Table, or the Table may be replaced with its next version.
+// Each published group contains M rows with M distinct values of the Sensitive column,
+// and an individual, identified by the value of the ID column, is always published in
+// a group with the same set (signature) of sensitive values, so intersecting the releases
+// does not narrow down the sensitive value of anyone. When the rows of a version do not
+// allow this, counterfeit rows are added to the groups. The number of counterfeit rows
+// in each group is published in the release, but not which rows are counterfeit.
+// The ID and Sensitive columns must be non-identifier columns; the ID column is suppressed
+// in the releases. The Table is not modified, and neither is the history, when an error is returned.
+func (a *Anonymizer) Publish() (*Release, error) {
+	r := a.republisher.clone()
+	r.M, r.ID, r.Sensitive, r.Observer, r.Cost = a.M, a.ID, a.Sensitive, a.Observer, a.cost()
+	release, err := r.publish(a.Table)
+	if err != nil {
+		return nil, err
+	}
+	a.republisher = r
+	return release, nil
+}
+
+// History returns the releases published so far, the oldest first.
+func (a *Anonymizer) History() []*Release {
+	if a.republisher == nil {
+		return nil
+	}
+	return a.republisher.history
+}
+
+// clone returns a copy of the republisher, which can be changed by a publication without
+// changing the history of the original. A nil republisher is cloned as an empty one.
+func (r *republisher) clone() *republisher {
+	c := &republisher{
+		signatures: make(map[string][]string),
+		values:     make(map[string]partition.Partition),
+	}
+	if r == nil {
+		return c
+	}
+	c.history = append(c.history, r.history...)
+	for id, signature := range r.signatures {
+		c.signatures[id] = signature
+	}
+	for value, p := range r.values {
+		c.values[value] = p
+	}
+	return c
+}
+
+// publish publishes the next version of the table, and updates the history of the republisher.
+// The republisher is left partially updated on error, so it must be a clone (see clone).
+func (r *republisher) publish(table *model.Table) (*Release, error) {
+	idIdx, sIdx, err := r.validate(table.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	buckets := make(map[string]*bucket)
+	pool := make(map[string][]*tuple)
+	seen := make(map[string]bool)
+	for _, row := range table.GetRows() {
+		t := &tuple{
+			row:   &model.Row{Data: append([]partition.Partition(nil), row.Data...)},
+			id:    row.Data[idIdx].String(),
+			value: row.Data[sIdx].String(),
+		}
+		if seen[t.id] {
+			return nil, fmt.Errorf("duplicate individual in column %s: %s", r.ID, t.id)
+		}
+		seen[t.id] = true
+		r.values[t.value] = row.Data[sIdx]
+		if signature, ok := r.signatures[t.id]; ok {
+			if err := divide(buckets, signature, t); err != nil {
+				return nil, err
+			}
+		} else {
+			pool[t.value] = append(pool[t.value], t)
+		}
+	}
+	columns := len(table.GetSchema().Columns)
+	r.balance(buckets, pool, columns, sIdx)
+	if err := r.assign(buckets, pool, columns, sIdx); err != nil {
+		return nil, err
+	}
+	return r.split(table.GetSchema(), buckets, idIdx)
+}
+
+func (r *republisher) validate(schema *model.Schema) (int, int, error) {
+	if r.M < 2 {
+		return 0, 0, fmt.Errorf("invalid value for M: %d", r.M)
+	}
+	idIdx := schema.IndexOf(r.ID)
+	sIdx := schema.IndexOf(r.Sensitive)
+	if idIdx < 0 || sIdx < 0 {
+		return 0, 0, fmt.Errorf("missing ID or sensitive column: %s, %s", r.ID, r.Sensitive)
+	}
+	if idIdx == sIdx {
+		return 0, 0, errors.New("the ID and sensitive columns must be different")
+	}
+	if schema.Columns[idIdx].IsIdentifier() || schema.Columns[sIdx].IsIdentifier() {
+		return 0, 0, errors.New("the ID and sensitive columns must be non-identifier columns")
+	}
+	return idIdx, sIdx, nil
+}
+
+// divide puts a tuple of an individual, who was published before, into the bucket of its signature.
+func divide(buckets map[string]*bucket, signature []string, t *tuple) error {
+	if !contains(signature, t.value) {
+		return fmt.Errorf("sensitive value of individual %s changed to %s, which is not in its signature %v",
+			t.id, t.value, signature)
+	}
+	getBucket(buckets, signature).add(t)
+	return nil
+}
+
+// balance adds tuples to each bucket until it has the same number of tuples for each
+// value of its signature. New tuples are used where possible, counterfeits otherwise.
+func (r *republisher) balance(buckets map[string]*bucket, pool map[string][]*tuple, columns, sIdx int) {
+	for _, key := range sortedKeys(buckets) {
+		b := buckets[key]
+		n := b.size()
+		for _, value := range b.signature {
+			for len(b.tuples[value]) < n {
+				if len(pool[value]) > 0 {
+					b.add(pool[value][0])
+					pool[value] = pool[value][1:]
+				} else {
+					b.add(r.counterfeit(value, columns, sIdx))
+				}
+			}
+		}
+	}
+}
+
+// assign forms buckets from the new tuples, taking one tuple of each of the M most frequent
+// sensitive values at a time. The remaining tuples are completed with counterfeits.
+func (r *republisher) assign(buckets map[string]*bucket, pool map[string][]*tuple, columns, sIdx int) error {
+	for {
+		available := sortByFrequency(pool)
+		if len(available) == 0 {
+			return nil
+		}
+		signature := available[:min(r.M, len(available))]
+		if len(signature) < r.M {
+			for _, value := range r.domain() {
+				if len(signature) < r.M && !contains(signature, value) {
+					signature = append(signature, value)
+				}
+			}
+			if len(signature) < r.M {
+				return fmt.Errorf("column %s has less than %d distinct values", r.Sensitive, r.M)
+			}
+		}
+		sort.Strings(signature)
+		b := getBucket(buckets, signature)
+		for _, value := range signature {
+			if len(pool[value]) > 0 {
+				b.add(pool[value][0])
+				pool[value] = pool[value][1:]
+			} else {
+				b.add(r.counterfeit(value, columns, sIdx))
+			}
+		}
+	}
+}
+
+// domain returns the sensitive values ever published, the most frequent first.
+func (r *republisher) domain() []string {
+	counts := make(map[string]int)
+	for value := range r.values {
+		counts[value] = 0
+	}
+	for _, signature := range r.signatures {
+		for _, value := range signature {
+			counts[value]++
+		}
+	}
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// split divides the buckets into groups with one tuple for each value of the signature,
+// picking the tuples with the lowest generalization cost, and generalizes the groups.
+func (r *republisher) split(schema *model.Schema, buckets map[string]*bucket, idIdx int) (*Release, error) {
+	release := &Release{
+		Version: len(r.history) + 1,
+		Table:   model.NewTable(schema),
+	}
+	for _, key := range sortedKeys(buckets) {
+		b := buckets[key]
+		for b.size() > 0 {
+			group, err := b.pick(schema, r.Cost)
+			if err != nil {
+				return nil, err
+			}
+			counterfeits := 0
+			var indices []int
+			for _, t := range group {
+				t.row.Data[idIdx] = partition.NewItem("*")
+				if t.counterfeit {
+					counterfeits++
+				} else {
+					r.signatures[t.id] = b.signature
+				}
+				indices = append(indices, len(release.Table.GetRows()))
+				release.Table.AppendRows(t.row)
+			}
+			release.Groups = append(release.Groups, indices)
+			release.Counterfeits = append(release.Counterfeits, counterfeits)
+		}
+	}
+	anon := &Anonymizer{Table: release.Table, Observer: r.Observer}
+	anon.generalize(release.Groups)
+	r.history = append(r.history, release)
+	return release, nil
+}
+
+// counterfeit creates a tuple with the given sensitive value. The identifier columns
+// are filled in from the group, which the counterfeit tuple is assigned to.
+func (r *republisher) counterfeit(value string, columns, sIdx int) *tuple {
+	data := make([]partition.Partition, columns)
+	data[sIdx] = r.values[value]
+	return &tuple{
+		row:         &model.Row{Data: data},
+		value:       value,
+		counterfeit: true,
+	}
+}
+
+func getBucket(buckets map[string]*bucket, signature []string) *bucket {
+	key := strings.Join(signature, "\x00")
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{signature: signature, tuples: make(map[string][]*tuple)}
+		buckets[key] = b
+	}
+	return b
+}
+
+func (b *bucket) add(t *tuple) {
+	b.tuples[t.value] = append(b.tuples[t.value], t)
+}
+
+// size returns the largest number of tuples with the same value in the bucket.
+func (b *bucket) size() int {
+	n := 0
+	for _, tuples := range b.tuples {
+		n = max(n, len(tuples))
+	}
+	return n
+}
+
+// pick removes a group of tuples from the bucket: a real tuple as the seed, and a tuple
+// for each other value. Counterfeit tuples take on the identifier columns of the seed, so
+// they do not widen the generalization, and are preferred over the real tuples, which are
+// kept as seeds for the remaining groups. Otherwise the closest real tuple is picked.
+// Returns an error, when only counterfeit tuples are left in the bucket, or a cost cannot be calculated.
+func (b *bucket) pick(schema *model.Schema, costs algorithm.CostFunction) ([]*tuple, error) {
+	var seed *tuple
+	for _, value := range b.signature {
+		if i := b.indexOfReal(value); i >= 0 {
+			seed = b.remove(value, i)
+			break
+		}
+	}
+	if seed == nil {
+		return nil, fmt.Errorf("no real tuple left for signature %v", b.signature)
+	}
+	group := []*tuple{seed}
+	for _, value := range b.signature {
+		if value == seed.value {
+			continue
+		}
+		best := -1
+		bestCost := math.Inf(1)
+		for i, t := range b.tuples[value] {
+			if t.counterfeit {
+				best = i
+				break
+			}
+			cost, err := algorithm.CalculateCostWith(seed.row, t.row, schema, costs)
+			if err != nil {
+				return nil, err
+			}
+			if best < 0 || cost < bestCost {
+				best, bestCost = i, cost
+			}
+		}
+		t := b.remove(value, best)
+		if t.counterfeit {
+			for i, p := range t.row.Data {
+				if p == nil {
+					t.row.Data[i] = seed.row.Data[i]
+				}
+			}
+		}
+		group = append(group, t)
+	}
+	return group, nil
+}
+
+func (b *bucket) indexOfReal(value string) int {
+	for i, t := range b.tuples[value] {
+		if !t.counterfeit {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *bucket) remove(value string, i int) *tuple {
+	t := b.tuples[value][i]
+	b.tuples[value] = append(b.tuples[value][:i], b.tuples[value][i+1:]...)
+	return t
+}
+
+// sortByFrequency returns the values with tuples, the most frequent first.
+func sortByFrequency(pool map[string][]*tuple) []string {
+	var values []string
+	for value, tuples := range pool {
+		if len(tuples) > 0 {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		ni, nj := len(pool[values[i]]), len(pool[values[j]])
+		if ni != nj {
+			return ni > nj
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+func sortedKeys(buckets map[string]*bucket) []string {
+	keys := make([]string, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
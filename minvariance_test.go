@@ -0,0 +1,206 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+var diseases = []string{"flu", "gastritis", "dyspepsia", "bronchitis", "pneumonia"}
+
+func TestAnonymizer_Publish(t *testing.T) {
+
+	t.Run("groups have m distinct sensitive values", func(t *testing.T) {
+		a := &Anonymizer{M: 3, ID: "ID", Sensitive: "Disease"}
+		a.Table = getPatientTable(rand.New(rand.NewSource(1)), 0, 30)
+		release, err := a.Publish()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(1, release.Version, t)
+		assertMInvariantRelease(t, a, release)
+	})
+
+	t.Run("signatures are kept across releases", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(2))
+		a := &Anonymizer{M: 3, ID: "ID", Sensitive: "Disease"}
+		versions := []*model.Table{
+			getPatientTable(rnd, 0, 30),
+			getPatientTable(rnd, 10, 45), // 10 deleted, 15 inserted
+			getPatientTable(rnd, 20, 40), // 10 deleted, 5 reinserted
+		}
+		signatures := make(map[string][]string)
+		for i, table := range versions {
+			a.Table = table
+			release, err := a.Publish()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.AssertEquals(i+1, release.Version, t)
+			assertMInvariantRelease(t, a, release)
+			for id, signature := range a.republisher.signatures {
+				if previous, ok := signatures[id]; ok {
+					testutil.AssertEquals(fmt.Sprint(previous), fmt.Sprint(signature), t)
+				}
+				signatures[id] = signature
+			}
+		}
+		testutil.AssertEquals(3, len(a.History()), t)
+	})
+
+	t.Run("counterfeits are added to unbalanced buckets", func(t *testing.T) {
+		a := &Anonymizer{M: 2, ID: "ID", Sensitive: "Disease"}
+		a.Table = getPatientSchemaTable()
+		a.Table.AddRow("1", 30, "flu")
+		a.Table.AddRow("2", 31, "gastritis")
+		if _, err := a.Publish(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		a.Table = getPatientSchemaTable()
+		a.Table.AddRow("1", 30, "flu")
+		release, err := a.Publish()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(2, len(release.Table.GetRows()), t)
+		testutil.AssertEquals(1, release.Counterfeits[0], t)
+		assertMInvariantRelease(t, a, release)
+	})
+
+	t.Run("input table is not modified", func(t *testing.T) {
+		a := &Anonymizer{M: 2, ID: "ID", Sensitive: "Disease"}
+		a.Table = getPatientTable(rand.New(rand.NewSource(3)), 0, 10)
+		expected := a.Table.String()
+		if _, err := a.Publish(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(expected, a.Table.String(), t)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		duplicate := getPatientSchemaTable()
+		duplicate.AddRow("1", 30, "flu")
+		duplicate.AddRow("1", 31, "gastritis")
+		tests := []struct {
+			name string
+			a    *Anonymizer
+		}{
+			{"invalid M", &Anonymizer{M: 1, ID: "ID", Sensitive: "Disease", Table: getPatientSchemaTable()}},
+			{"missing column", &Anonymizer{M: 2, ID: "SSN", Sensitive: "Disease", Table: getPatientSchemaTable()}},
+			{"identifier column", &Anonymizer{M: 2, ID: "ID", Sensitive: "Age", Table: getPatientSchemaTable()}},
+			{"duplicate individual", &Anonymizer{M: 2, ID: "ID", Sensitive: "Disease", Table: duplicate}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if _, err := test.a.Publish(); err == nil {
+					t.Errorf("expected error")
+				}
+			})
+		}
+	})
+
+	t.Run("changed sensitive value", func(t *testing.T) {
+		a := &Anonymizer{M: 2, ID: "ID", Sensitive: "Disease"}
+		a.Table = getPatientSchemaTable()
+		a.Table.AddRow("1", 30, "flu")
+		a.Table.AddRow("2", 31, "gastritis")
+		if _, err := a.Publish(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		a.Table = getPatientSchemaTable()
+		a.Table.AddRow("3", 32, "bronchitis")
+		a.Table.AddRow("1", 30, "pneumonia")
+		if _, err := a.Publish(); err == nil {
+			t.Errorf("expected error")
+		}
+		testutil.AssertEquals(1, len(a.History()), t)
+		testutil.AssertEquals(2, len(a.republisher.signatures), t)
+		if _, ok := a.republisher.values["bronchitis"]; ok {
+			t.Errorf("sensitive value of the failed release is recorded")
+		}
+	})
+
+	t.Run("cost error", func(t *testing.T) {
+		costErr := errors.New("cost error")
+		a := &Anonymizer{M: 2, ID: "ID", Sensitive: "Disease"}
+		a.Cost = algorithm.CostFunc(func(p1, p2 partition.Partition, col *model.Column) (float64, error) {
+			return 0, costErr
+		})
+		a.Table = getPatientSchemaTable()
+		a.Table.AddRow("1", 30, "flu")
+		a.Table.AddRow("2", 31, "gastritis")
+		if _, err := a.Publish(); !errors.Is(err, costErr) {
+			t.Errorf("expected %v, got %v", costErr, err)
+		}
+		testutil.AssertEquals(0, len(a.History()), t)
+	})
+}
+
+func TestBucket_Pick(t *testing.T) {
+	b := &bucket{signature: []string{"flu", "gastritis"}, tuples: make(map[string][]*tuple)}
+	b.add(&tuple{value: "flu", counterfeit: true})
+	b.add(&tuple{value: "gastritis", counterfeit: true})
+	if _, err := b.pick(getPatientSchemaTable().GetSchema(), algorithm.LevelCost{}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func assertMInvariantRelease(t *testing.T, a *Anonymizer, release *Release) {
+	t.Helper()
+	schema := release.Table.GetSchema()
+	idIdx := schema.IndexOf(a.ID)
+	sIdx := schema.IndexOf(a.Sensitive)
+	for i, group := range release.Groups {
+		testutil.AssertEquals(a.M, len(group), t)
+		values := make(map[string]bool)
+		for _, rowIdx := range group {
+			row := release.Table.GetRows()[rowIdx]
+			testutil.AssertEquals("*", row.Data[idIdx].String(), t)
+			values[row.Data[sIdx].String()] = true
+		}
+		testutil.AssertEquals(a.M, len(values), t)
+		if release.Counterfeits[i] >= a.M {
+			t.Errorf("group %d has only counterfeit rows", i)
+		}
+		rows := make([]*model.Row, len(group))
+		for j, rowIdx := range group {
+			rows[j] = release.Table.GetRows()[rowIdx]
+		}
+		for colIdx, col := range schema.Columns {
+			if col.IsIdentifier() && !samePartition(colIdx, rows) {
+				t.Errorf("group %d is not generalized in column %s", i, col.GetName())
+			}
+		}
+	}
+}
+
+// getPatientTable returns the patients with IDs in [from, to), with the sensitive value
+// and age of each patient derived from the ID, so they are the same in every version.
+func getPatientTable(rnd *rand.Rand, from, to int) *model.Table {
+	table := getPatientSchemaTable()
+	for id := from; id < to; id++ {
+		table.AddRow(fmt.Sprint(id), 20+(id*7)%60, diseases[id%len(diseases)])
+	}
+	rnd.Shuffle(len(table.GetRows()), func(i, j int) {
+		rows := table.GetRows()
+		rows[i], rows[j] = rows[j], rows[i]
+	})
+	return table
+}
+
+func getPatientSchemaTable() *model.Table {
+	return model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("ID", nil),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 150)),
+			model.NewColumn("Disease", nil),
+		},
+	})
+}
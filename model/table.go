@@ -2,10 +2,13 @@ package model
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
 	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/transformation"
 )
 
 // Table contains data organized in a rectangular shape. It has a fixed
@@ -30,6 +33,10 @@ func (t *Table) AddRow(items ...interface{}) {
 		var p partition.Partition
 		if col.g != nil {
 			p = t.schema.Columns[i].g.InitItem(item)
+		} else if col.items != nil {
+			p = newItemSet(item)
+		} else if col.trajectory != nil {
+			p = col.trajectory.InitItem(item)
 		} else {
 			p = partition.NewItem(item)
 		}
@@ -38,6 +45,11 @@ func (t *Table) AddRow(items ...interface{}) {
 	t.rows = append(t.rows, &Row{Data: data})
 }
 
+// AppendRows appends existing rows to the table. The rows must conform to the table schema.
+func (t *Table) AppendRows(rows ...*Row) {
+	t.rows = append(t.rows, rows...)
+}
+
 func (t *Table) GetSchema() *Schema {
 	return t.schema
 }
@@ -46,6 +58,17 @@ func (t *Table) GetRows() []*Row {
 	return t.rows
 }
 
+// Select returns a table with the same schema, containing the rows of t at the given indices.
+// The rows are shared between the two tables, so changing the data of a row in the returned
+// table changes the data in t as well.
+func (t *Table) Select(indices []int) *Table {
+	rows := make([]*Row, len(indices))
+	for i, idx := range indices {
+		rows[i] = t.rows[idx]
+	}
+	return &Table{schema: t.schema, rows: rows}
+}
+
 func (t *Table) String() string {
 	sb := &strings.Builder{}
 	t.appendHeader(sb)
@@ -69,18 +92,64 @@ func (t *Table) appendHeader(sb *strings.Builder) {
 }
 
 // Schema defines the number of columns, and their attributes in a table.
+// QuasiIdentifiers declares sets of identifier columns, each with its own K. Without
+// them, all identifier columns form a single set with the K of the anonymizer.
 type Schema struct {
-	Columns []*Column
+	Columns          []*Column
+	QuasiIdentifiers []*QuasiIdentifier
+}
+
+// QuasiIdentifier is a set of identifier columns, which a recipient of the data could link
+// with other data sources, and the K required for the set. Zero K stands for the K of the anonymizer.
+type QuasiIdentifier struct {
+	Columns []string
+	K       int
+}
+
+// Restrict returns a schema with the same columns, where only the given columns are identifiers.
+func (s *Schema) Restrict(names []string) *Schema {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+	result := &Schema{Columns: make([]*Column, len(s.Columns))}
+	for i, col := range s.Columns {
+		c := *col
+		if !keep[col.name] {
+			c.g = nil
+		}
+		result.Columns[i] = &c
+	}
+	return result
+}
+
+// IndexOf returns the index of the column with the given name, or -1 if there is no such column.
+func (s *Schema) IndexOf(name string) int {
+	for i, col := range s.Columns {
+		if col.name == name {
+			return i
+		}
+	}
+	return -1
 }
 
 // Column represents a column definition in a table schema.
 // If the generalizer is set to nil, the column will be treated as non-identifier.
 // Weight is a positive floating point number, which adjusts the cost of a column
 // when picked for generalization (default is 1.0).
+// Non-identifier columns can have a transformer, which masks each value on its own.
+// The generalization of an identifier column can be limited to a maximum level with WithMaxLevel.
+// Set-valued columns hold a set of items in each row, and have an item hierarchy instead of a generalizer.
+// Trajectory columns hold a sequence of (place, time) points in each row.
 type Column struct {
-	name   string
-	g      generalization.Generalizer
-	weight float64
+	name       string
+	g          generalization.Generalizer
+	weight     float64
+	t          transformation.Transformer
+	limited    bool
+	maxLevel   int
+	items      hierarchy.Hierarchy
+	trajectory *generalization.TrajectoryGeneralizer
 }
 
 func NewColumn(name string, g generalization.Generalizer) *Column {
@@ -94,7 +163,34 @@ func NewWeightedColumn(name string, g generalization.Generalizer, w float64) *Co
 	} else {
 		adjustedWeight = w
 	}
-	return &Column{name, g, adjustedWeight}
+	return &Column{name: name, g: g, weight: adjustedWeight}
+}
+
+// NewSetValuedColumn creates a column, which holds a set of items in each row, such as purchased
+// items or diagnosis codes. Rows are added with a slice of items for the column, which is stored as
+// a partition.Set. The items are generalized with the item hierarchy by the KMAnonymizer, while the
+// graph based anonymizer treats the column as a non-identifier column.
+func NewSetValuedColumn(name string, items hierarchy.Hierarchy) *Column {
+	c := NewColumn(name, nil)
+	c.items = items
+	return c
+}
+
+// NewTrajectoryColumn creates a column, which holds a trajectory in each row, such as the places
+// visited by a user. Rows are added with a slice of generalization.Visit values for the column, which
+// is stored as a partition.Trajectory. The trajectories are generalized with the place and time generalizers
+// by the TrajectoryAnonymizer, while the graph based anonymizer treats the column as a non-identifier column.
+func NewTrajectoryColumn(name string, place, time generalization.Generalizer) *Column {
+	c := NewColumn(name, nil)
+	c.trajectory = generalization.NewTrajectoryGeneralizer(place, time)
+	return c
+}
+
+// NewTransformedColumn creates a non-identifier column, which values are masked with the given transformer.
+func NewTransformedColumn(name string, t transformation.Transformer) *Column {
+	c := NewColumn(name, nil)
+	c.t = t
+	return c
 }
 
 func (c *Column) GetName() string {
@@ -109,11 +205,84 @@ func (c *Column) GetWeight() float64 {
 	return c.weight
 }
 
+func (c *Column) GetTransformer() transformation.Transformer {
+	return c.t
+}
+
+// GetMaxLevel returns the highest level, which the values of the column may be generalized to.
+// Without a limit this is the highest level of the generalizer.
+func (c *Column) GetMaxLevel() int {
+	if c.g == nil {
+		return 0
+	}
+	top := c.g.Levels() - 1
+	if c.limited && c.maxLevel < top {
+		return max(0, c.maxLevel)
+	}
+	return top
+}
+
+// IsLimited returns true, when the column may not be generalized to the highest level of its generalizer.
+func (c *Column) IsLimited() bool {
+	return c.g != nil && c.GetMaxLevel() < c.g.Levels()-1
+}
+
+// WithMaxLevel limits the generalization of the column to the given level of its generalizer,
+// and returns the column. Rows, which cannot be K-anonymized within the limits, either fail
+// the anonymization, or are suppressed (see Anonymizer.Suppress); suppressed rows are not
+// part of the K-anonymous groups.
+func (c *Column) WithMaxLevel(level int) *Column {
+	c.limited = true
+	c.maxLevel = level
+	return c
+}
+
+// IsSetValued returns true for columns created with NewSetValuedColumn.
+func (c *Column) IsSetValued() bool {
+	return c.items != nil
+}
+
+// GetItemHierarchy returns the item hierarchy of a set-valued column.
+func (c *Column) GetItemHierarchy() hierarchy.Hierarchy {
+	return c.items
+}
+
+// IsTrajectory returns true for columns created with NewTrajectoryColumn.
+func (c *Column) IsTrajectory() bool {
+	return c.trajectory != nil
+}
+
+// GetTrajectoryGeneralizer returns the generalizer of a trajectory column.
+func (c *Column) GetTrajectoryGeneralizer() *generalization.TrajectoryGeneralizer {
+	return c.trajectory
+}
+
 func (c *Column) IsIdentifier() bool {
 	return c.g != nil
 }
 
+func (c *Column) IsTransformed() bool {
+	return c.t != nil
+}
+
 // Row represents a row of data in a table.
 type Row struct {
 	Data []partition.Partition
 }
+
+// newItemSet creates the set of items of a set-valued column from a slice of items,
+// or from a single item.
+func newItemSet(item interface{}) *partition.Set {
+	if s, ok := item.(*partition.Set); ok {
+		return s
+	}
+	v := reflect.ValueOf(item)
+	if v.Kind() != reflect.Slice {
+		return partition.NewSet(item)
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return partition.NewSet(items...)
+}
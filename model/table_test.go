@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
 	"github.com/gar-r/k-anon/partition"
 	"github.com/gar-r/k-anon/testutil"
+	"github.com/gar-r/k-anon/transformation"
 )
 
 func TestNewTable(t *testing.T) {
@@ -57,6 +59,88 @@ func TestTable_GetRows(t *testing.T) {
 	}
 }
 
+func TestTable_Select(t *testing.T) {
+	table := NewTable(&Schema{
+		Columns: []*Column{{name: "Col1"}},
+	})
+	table.AddRow("a")
+	table.AddRow("b")
+	table.AddRow("c")
+	selected := table.Select([]int{2, 0})
+	testutil.AssertEquals(table.GetSchema(), selected.GetSchema(), t)
+	testutil.AssertEquals(2, len(selected.GetRows()), t)
+	testutil.AssertEquals(table.GetRows()[2], selected.GetRows()[0], t)
+	testutil.AssertEquals(table.GetRows()[0], selected.GetRows()[1], t)
+}
+
+func TestTable_AppendRows(t *testing.T) {
+	table := NewTable(&Schema{
+		Columns: []*Column{{name: "Col1"}},
+	})
+	table.AddRow("a")
+	row := &Row{Data: []partition.Partition{partition.NewItem("b")}}
+	table.AppendRows(row)
+	testutil.AssertEquals(2, len(table.GetRows()), t)
+	testutil.AssertEquals(row, table.GetRows()[1], t)
+}
+
+func TestSchema_IndexOf(t *testing.T) {
+	schema := &Schema{
+		Columns: []*Column{{name: "Col1"}, {name: "Col2"}},
+	}
+	testutil.AssertEquals(1, schema.IndexOf("Col2"), t)
+	testutil.AssertEquals(-1, schema.IndexOf("Col3"), t)
+}
+
+func TestSchema_Restrict(t *testing.T) {
+	g := generalization.ExampleIntGeneralizer()
+	schema := &Schema{
+		Columns: []*Column{
+			NewWeightedColumn("Col1", g, 2).WithMaxLevel(1),
+			NewColumn("Col2", g),
+			NewColumn("Col3", nil),
+		},
+	}
+	restricted := schema.Restrict([]string{"Col1", "Col3"})
+	testutil.AssertEquals(3, len(restricted.Columns), t)
+	testutil.AssertEquals(true, restricted.Columns[0].IsIdentifier(), t)
+	testutil.AssertEquals(2.0, restricted.Columns[0].GetWeight(), t)
+	testutil.AssertEquals(1, restricted.Columns[0].GetMaxLevel(), t)
+	testutil.AssertEquals(false, restricted.Columns[1].IsIdentifier(), t)
+	testutil.AssertEquals(false, restricted.Columns[2].IsIdentifier(), t)
+	testutil.AssertEquals(true, schema.Columns[1].IsIdentifier(), t)
+}
+
+func TestTable_AddRow_SetValued(t *testing.T) {
+	table := NewTable(&Schema{
+		Columns: []*Column{
+			NewColumn("Name", nil),
+			NewSetValuedColumn("Grades", hierarchy.GetGradeHierarchy()),
+		},
+	})
+	table.AddRow("Alice", []string{"A", "B+"})
+	table.AddRow("Bob", "C")
+	testutil.AssertEquals(true, table.GetSchema().Columns[1].IsSetValued(), t)
+	testutil.AssertEquals(false, table.GetSchema().Columns[1].IsIdentifier(), t)
+	testutil.AssertEquals(true, table.GetRows()[0].Data[1].Equals(partition.NewSet("A", "B+")), t)
+	testutil.AssertEquals(true, table.GetRows()[1].Data[1].Equals(partition.NewSet("C")), t)
+}
+
+func TestTable_AddRow_Trajectory(t *testing.T) {
+	table := NewTable(&Schema{
+		Columns: []*Column{
+			NewColumn("Name", nil),
+			NewTrajectoryColumn("Route", generalization.ExampleGradeGeneralizer(), generalization.NewIntRangeGeneralizer(0, 23)),
+		},
+	})
+	table.AddRow("Alice", []generalization.Visit{{Place: "A", Time: 8}, {Place: "B", Time: 17}})
+	col := table.GetSchema().Columns[1]
+	testutil.AssertEquals(true, col.IsTrajectory(), t)
+	testutil.AssertEquals(false, col.IsIdentifier(), t)
+	testutil.AssertNotNil(col.GetTrajectoryGeneralizer(), t)
+	testutil.AssertEquals("<([A], [8]) ([B], [17])>", table.GetRows()[0].Data[1].String(), t)
+}
+
 func TestTable_GetSchema(t *testing.T) {
 	schema := &Schema{}
 	table := NewTable(schema)
@@ -81,6 +165,33 @@ func TestColumn_IsIdentifier(t *testing.T) {
 
 }
 
+func TestColumn_WithMaxLevel(t *testing.T) {
+
+	t.Run("unlimited", func(t *testing.T) {
+		col := NewColumn("Col1", generalization.ExampleIntGeneralizer())
+		testutil.AssertEquals(4, col.GetMaxLevel(), t)
+		testutil.AssertEquals(false, col.IsLimited(), t)
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		col := NewColumn("Col1", generalization.ExampleIntGeneralizer()).WithMaxLevel(2)
+		testutil.AssertEquals(2, col.GetMaxLevel(), t)
+		testutil.AssertEquals(true, col.IsLimited(), t)
+	})
+
+	t.Run("limit above the generalizer", func(t *testing.T) {
+		col := NewColumn("Col1", generalization.ExampleIntGeneralizer()).WithMaxLevel(10)
+		testutil.AssertEquals(4, col.GetMaxLevel(), t)
+		testutil.AssertEquals(false, col.IsLimited(), t)
+	})
+
+	t.Run("non-identifier column", func(t *testing.T) {
+		col := NewColumn("Col1", nil).WithMaxLevel(1)
+		testutil.AssertEquals(0, col.GetMaxLevel(), t)
+		testutil.AssertEquals(false, col.IsLimited(), t)
+	})
+}
+
 func TestTable_String(t *testing.T) {
 	table := NewTable(&Schema{
 		Columns: []*Column{
@@ -118,6 +229,26 @@ func TestColumn_GetWeight(t *testing.T) {
 	}
 }
 
+func TestColumn_GetTransformer(t *testing.T) {
+	c := NewColumn("test", nil)
+	testutil.AssertNil(c.GetTransformer(), t)
+}
+
+func TestNewTransformedColumn(t *testing.T) {
+	cipher, _ := transformation.NewFF1(make([]byte, 16), 10)
+	tr, _ := transformation.NewFPETransformer(cipher, transformation.Digits, nil)
+	c := NewTransformedColumn("test", tr)
+	if c.GetTransformer() != tr {
+		t.Errorf("expected %v, got %v", tr, c.GetTransformer())
+	}
+	if !c.IsTransformed() {
+		t.Errorf("expected transformed column")
+	}
+	if c.IsIdentifier() {
+		t.Errorf("expected non-identifier column")
+	}
+}
+
 func TestNewColumn(t *testing.T) {
 
 	t.Run("default weight", func(t *testing.T) {
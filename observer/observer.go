@@ -0,0 +1,76 @@
+package observer
+
+import "time"
+
+// Observer receives progress events of an anonymization run.
+// Events are delivered synchronously from the goroutine running the
+// anonymization, so implementations should return quickly.
+type Observer interface {
+	// CostGraphBuilt is called when the cost graph of the table is complete.
+	CostGraphBuilt(e CostGraphEvent)
+
+	// ForestEdgeAdded is called each time an edge is added to the anonymization forest.
+	ForestEdgeAdded(e ForestEdgeEvent)
+
+	// ComponentCut is called each time the decomposition cuts a component of the forest.
+	ComponentCut(e CutEvent)
+
+	// GroupGeneralized is called each time a group of rows is generalized into the same partitions.
+	GroupGeneralized(e GroupEvent)
+}
+
+// CostGraphEvent describes a completed cost graph.
+type CostGraphEvent struct {
+	Nodes    int
+	Edges    int
+	Duration time.Duration
+}
+
+// ForestEdgeEvent describes an edge added to the anonymization forest.
+// Edges is the number of edges in the forest (including this one), and
+// Nodes is the number of rows; a forest never has more than Nodes-1 edges,
+// which can be used to estimate the remaining work.
+type ForestEdgeEvent struct {
+	From, To int64
+	Edges    int
+	Nodes    int
+}
+
+// CutType identifies the kind of a decomposition cut.
+type CutType string
+
+// The decomposition cut types, see algorithm.Decomposer for details.
+const (
+	CutA CutType = "A"
+	CutB CutType = "B"
+	CutC CutType = "C"
+	CutD CutType = "D"
+)
+
+// CutEvent describes a cut performed on a component of size Size.
+type CutEvent struct {
+	Type CutType
+	Size int
+}
+
+// GroupEvent describes a group of Size rows generalized into the same partitions.
+type GroupEvent struct {
+	Size     int
+	Duration time.Duration
+}
+
+// Nop is an Observer which ignores all events.
+// It can be embedded into custom observers, which only care about some of the events.
+type Nop struct{}
+
+// CostGraphBuilt ignores the event.
+func (Nop) CostGraphBuilt(CostGraphEvent) {}
+
+// ForestEdgeAdded ignores the event.
+func (Nop) ForestEdgeAdded(ForestEdgeEvent) {}
+
+// ComponentCut ignores the event.
+func (Nop) ComponentCut(CutEvent) {}
+
+// GroupGeneralized ignores the event.
+func (Nop) GroupGeneralized(GroupEvent) {}
@@ -0,0 +1,164 @@
+package observer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DurationBuckets are the default upper bounds (in seconds) of the duration histograms.
+var DurationBuckets = []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900}
+
+// SizeBuckets are the default upper bounds of the group size histogram.
+var SizeBuckets = []float64{2, 3, 4, 5, 10, 20, 50, 100}
+
+// Prometheus is an Observer, which collects counters and histograms from the events,
+// and exposes them in the Prometheus text exposition format.
+// It implements http.Handler, so it can be registered directly as a scrape endpoint.
+// A Prometheus observer can be shared between anonymizers, it is safe for concurrent use.
+type Prometheus struct {
+	mu               sync.Mutex
+	costGraphs       int
+	costGraphEdges   int
+	costGraphTime    *histogram
+	forestEdges      int
+	cuts             map[CutType]int
+	groups           int
+	rows             int
+	groupSizes       *histogram
+	generalizingTime *histogram
+}
+
+// NewPrometheus creates a new Prometheus observer with the default buckets.
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		costGraphTime:    newHistogram(DurationBuckets),
+		cuts:             make(map[CutType]int),
+		groupSizes:       newHistogram(SizeBuckets),
+		generalizingTime: newHistogram(DurationBuckets),
+	}
+}
+
+// CostGraphBuilt records the cost graph size and construction time.
+func (p *Prometheus) CostGraphBuilt(e CostGraphEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.costGraphs++
+	p.costGraphEdges = e.Edges
+	p.costGraphTime.observe(e.Duration.Seconds())
+}
+
+// ForestEdgeAdded counts the forest edges.
+func (p *Prometheus) ForestEdgeAdded(ForestEdgeEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.forestEdges++
+}
+
+// ComponentCut counts the decomposition cuts by type.
+func (p *Prometheus) ComponentCut(e CutEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cuts[e.Type]++
+}
+
+// GroupGeneralized counts the generalized groups and rows, and records the group sizes.
+func (p *Prometheus) GroupGeneralized(e GroupEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.groups++
+	p.rows += e.Size
+	p.groupSizes.observe(float64(e.Size))
+	p.generalizingTime.observe(e.Duration.Seconds())
+}
+
+// ServeHTTP writes the collected metrics in the Prometheus text format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTo writes the collected metrics in the Prometheus text format to w.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	writeMetric(cw, "kanon_cost_graphs_total", "counter", "Number of cost graphs built.", p.costGraphs)
+	writeMetric(cw, "kanon_cost_graph_edges", "gauge", "Number of edges in the last cost graph.", p.costGraphEdges)
+	p.costGraphTime.write(cw, "kanon_cost_graph_duration_seconds", "Time spent building cost graphs.")
+	writeMetric(cw, "kanon_forest_edges_total", "counter", "Number of edges added to anonymization forests.", p.forestEdges)
+	writeHeader(cw, "kanon_decomposition_cuts_total", "counter", "Number of decomposition cuts by type.")
+	for _, t := range []CutType{CutA, CutB, CutC, CutD} {
+		fmt.Fprintf(cw, "kanon_decomposition_cuts_total{type=%q} %d\n", t, p.cuts[t])
+	}
+	writeMetric(cw, "kanon_groups_generalized_total", "counter", "Number of generalized row groups.", p.groups)
+	writeMetric(cw, "kanon_rows_generalized_total", "counter", "Number of generalized rows.", p.rows)
+	p.groupSizes.write(cw, "kanon_group_size", "Size of the generalized row groups.")
+	p.generalizingTime.write(cw, "kanon_group_generalization_duration_seconds", "Time spent generalizing row groups.")
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+type histogram struct {
+	bounds []float64
+	counts []int
+	sum    float64
+	count  int
+}
+
+func newHistogram(bounds []float64) *histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return &histogram{bounds: sorted, counts: make([]int, len(sorted))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, help string) {
+	writeHeader(w, name, "histogram", help)
+	for i, b := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, b, h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %g\n", name, h.sum)
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func writeMetric(w io.Writer, name, kind, help string, value int) {
+	writeHeader(w, name, kind, help)
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
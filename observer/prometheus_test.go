@@ -0,0 +1,61 @@
+package observer
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheus_WriteTo(t *testing.T) {
+	p := NewPrometheus()
+	p.CostGraphBuilt(CostGraphEvent{Nodes: 4, Edges: 6, Duration: 20 * time.Millisecond})
+	p.ForestEdgeAdded(ForestEdgeEvent{From: 0, To: 1, Edges: 1, Nodes: 4})
+	p.ForestEdgeAdded(ForestEdgeEvent{From: 2, To: 3, Edges: 2, Nodes: 4})
+	p.ComponentCut(CutEvent{Type: CutB, Size: 8})
+	p.GroupGeneralized(GroupEvent{Size: 2})
+	p.GroupGeneralized(GroupEvent{Size: 3})
+
+	sb := &strings.Builder{}
+	n, err := p.WriteTo(sb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := sb.String()
+	if int(n) != len(out) {
+		t.Errorf("expected %d bytes written, got %d", len(out), n)
+	}
+	expected := []string{
+		"# TYPE kanon_cost_graphs_total counter\nkanon_cost_graphs_total 1\n",
+		"kanon_cost_graph_edges 6\n",
+		"kanon_cost_graph_duration_seconds_bucket{le=\"0.01\"} 0\n",
+		"kanon_cost_graph_duration_seconds_bucket{le=\"0.1\"} 1\n",
+		"kanon_cost_graph_duration_seconds_count 1\n",
+		"kanon_forest_edges_total 2\n",
+		"kanon_decomposition_cuts_total{type=\"A\"} 0\n",
+		"kanon_decomposition_cuts_total{type=\"B\"} 1\n",
+		"kanon_groups_generalized_total 2\n",
+		"kanon_rows_generalized_total 5\n",
+		"kanon_group_size_bucket{le=\"2\"} 1\n",
+		"kanon_group_size_bucket{le=\"3\"} 2\n",
+		"kanon_group_size_bucket{le=\"+Inf\"} 2\n",
+		"kanon_group_size_sum 5\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected output to contain %q, got:\n%s", e, out)
+		}
+	}
+}
+
+func TestPrometheus_ServeHTTP(t *testing.T) {
+	p := NewPrometheus()
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type: %v", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "kanon_forest_edges_total 0") {
+		t.Errorf("unexpected body: %v", rec.Body.String())
+	}
+}
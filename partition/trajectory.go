@@ -0,0 +1,84 @@
+package partition
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Point is a place visited at a time. Both the place and the time are partitions,
+// so a generalized point covers an area and a time interval.
+type Point struct {
+	Place Partition
+	Time  Partition
+}
+
+// Trajectory represents a sequence of points.
+type Trajectory struct {
+	Points []Point
+}
+
+// NewTrajectory creates a new trajectory from the given points.
+func NewTrajectory(points ...Point) *Trajectory {
+	return &Trajectory{Points: points}
+}
+
+// Contains returns true when one of the points of the trajectory contains the item.
+// Note, that the item must be a Point, otherwise the result is always false.
+func (t *Trajectory) Contains(item interface{}) bool {
+	p, success := item.(Point)
+	if !success {
+		return false
+	}
+	for _, point := range t.Points {
+		if point.contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsPartition returns true when the other partition is a trajectory of the same length,
+// and each point of this trajectory contains the point of the other trajectory at the same position.
+func (t *Trajectory) ContainsPartition(other Partition) bool {
+	t2, success := other.(*Trajectory)
+	if !success || len(t.Points) != len(t2.Points) {
+		return false
+	}
+	for i, point := range t.Points {
+		if !point.contains(t2.Points[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equals returns true when the other partition is a trajectory with the same points.
+func (t *Trajectory) Equals(other Partition) bool {
+	t2, success := other.(*Trajectory)
+	if !success || len(t.Points) != len(t2.Points) {
+		return false
+	}
+	for i, point := range t.Points {
+		if !point.Place.Equals(t2.Points[i].Place) || !point.Time.Equals(t2.Points[i].Time) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the string representation of the trajectory, listing the points in order.
+func (t *Trajectory) String() string {
+	points := make([]string, len(t.Points))
+	for i, point := range t.Points {
+		points[i] = fmt.Sprintf("(%v, %v)", point.Place, point.Time)
+	}
+	return fmt.Sprintf("<%s>", strings.Join(points, " "))
+}
+
+func (p Point) contains(other Point) bool {
+	return covers(p.Place, other.Place) && covers(p.Time, other.Time)
+}
+
+func covers(p, other Partition) bool {
+	return p.Equals(other) || p.ContainsPartition(other)
+}
@@ -0,0 +1,101 @@
+package partition
+
+import "testing"
+
+func getTestTrajectory() *Trajectory {
+	return NewTrajectory(
+		Point{Place: NewSet("A", "B"), Time: NewIntRange(8, 9)},
+		Point{Place: NewSet("C"), Time: NewIntRange(10, 11)},
+	)
+}
+
+func TestTrajectory_Contains(t *testing.T) {
+	tr := getTestTrajectory()
+
+	t.Run("contained point", func(t *testing.T) {
+		if !tr.Contains(Point{Place: NewSet("A"), Time: NewIntRange(9, 9)}) {
+			t.Errorf("expected %v to contain the point", tr)
+		}
+	})
+
+	t.Run("point outside", func(t *testing.T) {
+		if tr.Contains(Point{Place: NewSet("C"), Time: NewIntRange(8, 8)}) {
+			t.Errorf("expected %v not to contain the point", tr)
+		}
+	})
+
+	t.Run("not a point", func(t *testing.T) {
+		if tr.Contains("A") {
+			t.Errorf("expected %v not to contain the item", tr)
+		}
+	})
+}
+
+func TestTrajectory_ContainsPartition(t *testing.T) {
+	tr := getTestTrajectory()
+
+	t.Run("contained trajectory", func(t *testing.T) {
+		other := NewTrajectory(
+			Point{Place: NewSet("B"), Time: NewIntRange(8, 8)},
+			Point{Place: NewSet("C"), Time: NewIntRange(10, 11)},
+		)
+		if !tr.ContainsPartition(other) {
+			t.Errorf("expected %v to contain %v", tr, other)
+		}
+	})
+
+	t.Run("different length", func(t *testing.T) {
+		other := NewTrajectory(Point{Place: NewSet("B"), Time: NewIntRange(8, 8)})
+		if tr.ContainsPartition(other) {
+			t.Errorf("expected %v not to contain %v", tr, other)
+		}
+	})
+
+	t.Run("different order", func(t *testing.T) {
+		other := NewTrajectory(
+			Point{Place: NewSet("C"), Time: NewIntRange(10, 11)},
+			Point{Place: NewSet("B"), Time: NewIntRange(8, 8)},
+		)
+		if tr.ContainsPartition(other) {
+			t.Errorf("expected %v not to contain %v", tr, other)
+		}
+	})
+
+	t.Run("not a trajectory", func(t *testing.T) {
+		if tr.ContainsPartition(NewSet("A")) {
+			t.Errorf("expected %v not to contain a set", tr)
+		}
+	})
+}
+
+func TestTrajectory_Equals(t *testing.T) {
+	tr := getTestTrajectory()
+
+	t.Run("equal trajectories", func(t *testing.T) {
+		if !tr.Equals(getTestTrajectory()) {
+			t.Errorf("expected trajectories to be equal")
+		}
+	})
+
+	t.Run("different points", func(t *testing.T) {
+		other := getTestTrajectory()
+		other.Points[1].Time = NewIntRange(10, 12)
+		if tr.Equals(other) {
+			t.Errorf("expected trajectories to be different: %v, %v", tr, other)
+		}
+	})
+
+	t.Run("nil input", func(t *testing.T) {
+		if tr.Equals(nil) {
+			t.Errorf("trajectory should not be equal to nil")
+		}
+	})
+}
+
+func TestTrajectory_String(t *testing.T) {
+	expected := "<([A, B], [8..9]) ([C], [10..11])>"
+	actual := getTestTrajectory().String()
+	if expected != actual {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,173 @@
+package persist
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Partition types in the serialized form.
+const (
+	ItemPartition       = "item"
+	SetPartition        = "set"
+	IntRangePartition   = "int-range"
+	FloatRangePartition = "float-range"
+	TrajectoryPartition = "trajectory"
+)
+
+// Partition is the serialized form of a partition.
+type Partition struct {
+	Type   string   `json:"type"`
+	Item   *Value   `json:"item,omitempty"`
+	Items  []*Value `json:"items,omitempty"`
+	Min    float64  `json:"min,omitempty"`
+	Max    float64  `json:"max,omitempty"`
+	Points []*Point `json:"points,omitempty"`
+}
+
+// Point is the serialized form of a point of a trajectory.
+type Point struct {
+	Place *Partition `json:"place"`
+	Time  *Partition `json:"time"`
+}
+
+// Value is the serialized form of an item of a partition. Exactly one of the fields is set,
+// so the type of the item is restored along with its value.
+type Value struct {
+	String *string  `json:"string,omitempty"`
+	Int    *int     `json:"int,omitempty"`
+	Float  *float64 `json:"float,omitempty"`
+	Bool   *bool    `json:"bool,omitempty"`
+}
+
+// EncodePartition returns the serialized form of a partition.
+// Items of partitions must be strings, ints, float64s or bools.
+func EncodePartition(p partition.Partition) (*Partition, error) {
+	switch q := p.(type) {
+	case *partition.Item:
+		v, err := encodeValue(q.GetItem())
+		if err != nil {
+			return nil, err
+		}
+		return &Partition{Type: ItemPartition, Item: v}, nil
+	case *partition.Set:
+		items := make([]*Value, 0, len(q.Items))
+		for item := range q.Items {
+			v, err := encodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].key() < items[j].key()
+		})
+		return &Partition{Type: SetPartition, Items: items}, nil
+	case *partition.IntRange:
+		return &Partition{Type: IntRangePartition, Min: q.Min(), Max: q.Max()}, nil
+	case *partition.FloatRange:
+		return &Partition{Type: FloatRangePartition, Min: q.Min(), Max: q.Max()}, nil
+	case *partition.Trajectory:
+		points := make([]*Point, len(q.Points))
+		for i, point := range q.Points {
+			place, err := EncodePartition(point.Place)
+			if err != nil {
+				return nil, err
+			}
+			time, err := EncodePartition(point.Time)
+			if err != nil {
+				return nil, err
+			}
+			points[i] = &Point{Place: place, Time: time}
+		}
+		return &Partition{Type: TrajectoryPartition, Points: points}, nil
+	case nil:
+		return nil, errors.New("cannot encode nil partition")
+	}
+	return nil, fmt.Errorf("unsupported partition type: %T", p)
+}
+
+// Decode restores the partition from its serialized form.
+func (p *Partition) Decode() (partition.Partition, error) {
+	if p == nil {
+		return nil, errors.New("missing partition")
+	}
+	switch p.Type {
+	case ItemPartition:
+		item, err := p.Item.decode()
+		if err != nil {
+			return nil, err
+		}
+		return partition.NewItem(item), nil
+	case SetPartition:
+		items := make([]interface{}, len(p.Items))
+		for i, v := range p.Items {
+			item, err := v.decode()
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return partition.NewSet(items...), nil
+	case IntRangePartition:
+		return partition.NewIntRange(int(p.Min), int(p.Max)), nil
+	case FloatRangePartition:
+		return partition.NewFloatRange(p.Min, p.Max), nil
+	case TrajectoryPartition:
+		points := make([]partition.Point, len(p.Points))
+		for i, point := range p.Points {
+			if point == nil {
+				return nil, errors.New("missing point")
+			}
+			place, err := point.Place.Decode()
+			if err != nil {
+				return nil, err
+			}
+			time, err := point.Time.Decode()
+			if err != nil {
+				return nil, err
+			}
+			points[i] = partition.Point{Place: place, Time: time}
+		}
+		return partition.NewTrajectory(points...), nil
+	}
+	return nil, fmt.Errorf("unsupported partition type: %s", p.Type)
+}
+
+func encodeValue(item interface{}) (*Value, error) {
+	switch v := item.(type) {
+	case string:
+		return &Value{String: &v}, nil
+	case int:
+		return &Value{Int: &v}, nil
+	case float64:
+		return &Value{Float: &v}, nil
+	case bool:
+		return &Value{Bool: &v}, nil
+	}
+	return nil, fmt.Errorf("unsupported item type: %T", item)
+}
+
+func (v *Value) decode() (interface{}, error) {
+	switch {
+	case v == nil:
+		return nil, errors.New("missing item")
+	case v.String != nil:
+		return *v.String, nil
+	case v.Int != nil:
+		return *v.Int, nil
+	case v.Float != nil:
+		return *v.Float, nil
+	case v.Bool != nil:
+		return *v.Bool, nil
+	}
+	return nil, errors.New("item without value")
+}
+
+// key orders the items of sets, so the same set is always serialized the same way.
+func (v *Value) key() string {
+	item, _ := v.decode()
+	return fmt.Sprintf("%T:%v", item, item)
+}
@@ -0,0 +1,93 @@
+package persist
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+func TestEncodePartition(t *testing.T) {
+
+	t.Run("round trip", func(t *testing.T) {
+		tests := []partition.Partition{
+			partition.NewItem("text"),
+			partition.NewItem(42),
+			partition.NewItem(0.5),
+			partition.NewItem(true),
+			partition.NewSet("A", "B", "C"),
+			partition.NewSet(1, 2),
+			partition.NewIntRange(0, 150),
+			partition.NewIntRange(10, 10),
+			partition.NewFloatRange(-0.5, 0.25),
+			partition.NewTrajectory(
+				partition.Point{Place: partition.NewSet("A", "B"), Time: partition.NewIntRange(8, 9)},
+				partition.Point{Place: partition.NewItem("*"), Time: partition.NewIntRange(10, 10)},
+			),
+		}
+		for _, p := range tests {
+			t.Run(p.String(), func(t *testing.T) {
+				encoded, err := EncodePartition(p)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				b, err := json.Marshal(encoded)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				decoded := &Partition{}
+				if err := json.Unmarshal(b, decoded); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				actual, err := decoded.Decode()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !p.Equals(actual) {
+					t.Errorf("expected %v, got %v", p, actual)
+				}
+			})
+		}
+	})
+
+	t.Run("set items are ordered", func(t *testing.T) {
+		p1, _ := EncodePartition(partition.NewSet("C", "A", "B"))
+		p2, _ := EncodePartition(partition.NewSet("B", "C", "A"))
+		b1, _ := json.Marshal(p1)
+		b2, _ := json.Marshal(p2)
+		if string(b1) != string(b2) {
+			t.Errorf("expected identical encoding, got %s and %s", b1, b2)
+		}
+	})
+
+	t.Run("unsupported item", func(t *testing.T) {
+		if _, err := EncodePartition(partition.NewItem(struct{}{})); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("nil partition", func(t *testing.T) {
+		if _, err := EncodePartition(nil); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func TestPartition_Decode(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Partition
+	}{
+		{"nil", nil},
+		{"unknown type", &Partition{Type: "unknown"}},
+		{"missing item", &Partition{Type: ItemPartition}},
+		{"empty item", &Partition{Type: ItemPartition, Item: &Value{}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.p.Decode(); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
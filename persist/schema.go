@@ -0,0 +1,186 @@
+package persist
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Generalizer types in the serialized form. Other generalizers are serialized with
+// their Go type name, and without parameters.
+const (
+	IntRangeGeneralizer   = "int-range"
+	FloatRangeGeneralizer = "float-range"
+	SuppressorGeneralizer = "suppressor"
+	PrefixGeneralizer     = "prefix"
+	HierarchyGeneralizer  = "hierarchy"
+)
+
+// Schema is the serialized form of a table schema.
+type Schema struct {
+	Columns          []*Column          `json:"columns"`
+	QuasiIdentifiers []*QuasiIdentifier `json:"quasiIdentifiers,omitempty"`
+}
+
+// QuasiIdentifier is the serialized form of a quasi-identifier set.
+type QuasiIdentifier struct {
+	Columns []string `json:"columns"`
+	K       int      `json:"k,omitempty"`
+}
+
+// Column is the serialized form of a column definition. Transformers are not serialized,
+// as they typically hold secret keys, only the presence of a transformer is recorded.
+// MaxLevel is only set for columns with a generalization limit, Items only for set-valued columns,
+// and Trajectory only for trajectory columns.
+type Column struct {
+	Name        string       `json:"name"`
+	Weight      float64      `json:"weight"`
+	Generalizer *Generalizer `json:"generalizer,omitempty"`
+	MaxLevel    *int         `json:"maxLevel,omitempty"`
+	Items       *Hierarchy   `json:"items,omitempty"`
+	Trajectory  *Trajectory  `json:"trajectory,omitempty"`
+	Transformed bool         `json:"transformed,omitempty"`
+}
+
+// Trajectory is the serialized form of the place and time generalizers of a trajectory column.
+type Trajectory struct {
+	Place *Generalizer `json:"place"`
+	Time  *Generalizer `json:"time"`
+}
+
+// Generalizer is the serialized form of a generalizer and its parameters.
+type Generalizer struct {
+	Type      string     `json:"type"`
+	Min       float64    `json:"min,omitempty"`
+	Max       float64    `json:"max,omitempty"`
+	MaxWords  int        `json:"maxWords,omitempty"`
+	Hierarchy *Hierarchy `json:"hierarchy,omitempty"`
+}
+
+// Hierarchy is the serialized form of a generalization hierarchy.
+type Hierarchy struct {
+	Partition *Partition   `json:"partition"`
+	Children  []*Hierarchy `json:"children,omitempty"`
+}
+
+// EncodeSchema returns the serialized form of a table schema.
+func EncodeSchema(s *model.Schema) (*Schema, error) {
+	result := &Schema{}
+	for _, col := range s.Columns {
+		c := &Column{
+			Name:        col.GetName(),
+			Weight:      col.GetWeight(),
+			Transformed: col.IsTransformed(),
+		}
+		if col.IsIdentifier() {
+			g, err := EncodeGeneralizer(col.GetGeneralizer())
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode column %s: %w", col.GetName(), err)
+			}
+			c.Generalizer = g
+			if col.IsLimited() {
+				level := col.GetMaxLevel()
+				c.MaxLevel = &level
+			}
+		}
+		if col.IsSetValued() {
+			h, err := encodeHierarchy(col.GetItemHierarchy())
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode column %s: %w", col.GetName(), err)
+			}
+			c.Items = h
+		}
+		if col.IsTrajectory() {
+			t, err := encodeTrajectory(col.GetTrajectoryGeneralizer())
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode column %s: %w", col.GetName(), err)
+			}
+			c.Trajectory = t
+		}
+		result.Columns = append(result.Columns, c)
+	}
+	for _, qi := range s.QuasiIdentifiers {
+		result.QuasiIdentifiers = append(result.QuasiIdentifiers, &QuasiIdentifier{
+			Columns: append([]string(nil), qi.Columns...),
+			K:       qi.K,
+		})
+	}
+	return result, nil
+}
+
+// EncodeGeneralizer returns the serialized form of a generalizer.
+func EncodeGeneralizer(g generalization.Generalizer) (*Generalizer, error) {
+	switch q := g.(type) {
+	case *generalization.RangeGeneralizer:
+		r := q.Range()
+		if _, ok := r.(*partition.IntRange); ok {
+			return &Generalizer{Type: IntRangeGeneralizer, Min: r.Min(), Max: r.Max()}, nil
+		}
+		return &Generalizer{Type: FloatRangeGeneralizer, Min: r.Min(), Max: r.Max()}, nil
+	case *generalization.Suppressor:
+		return &Generalizer{Type: SuppressorGeneralizer}, nil
+	case *generalization.PrefixGeneralizer:
+		return &Generalizer{Type: PrefixGeneralizer, MaxWords: q.MaxWords}, nil
+	case *generalization.HierarchyGeneralizer:
+		h, err := encodeHierarchy(q.Hierarchy)
+		if err != nil {
+			return nil, err
+		}
+		return &Generalizer{Type: HierarchyGeneralizer, Hierarchy: h}, nil
+	}
+	return &Generalizer{Type: fmt.Sprintf("%T", g)}, nil
+}
+
+func encodeTrajectory(g *generalization.TrajectoryGeneralizer) (*Trajectory, error) {
+	place, err := EncodeGeneralizer(g.Place)
+	if err != nil {
+		return nil, err
+	}
+	time, err := EncodeGeneralizer(g.Time)
+	if err != nil {
+		return nil, err
+	}
+	return &Trajectory{Place: place, Time: time}, nil
+}
+
+func encodeHierarchy(h hierarchy.Hierarchy) (*Hierarchy, error) {
+	p, err := EncodePartition(h.Partition())
+	if err != nil {
+		return nil, err
+	}
+	result := &Hierarchy{Partition: p}
+	for _, child := range h.Children() {
+		c, err := encodeHierarchy(child)
+		if err != nil {
+			return nil, err
+		}
+		result.Children = append(result.Children, c)
+	}
+	return result, nil
+}
+
+// Compatible returns an error if the given schema differs from the serialized one in
+// the number of columns, in the name, weight, generalizer, limit or transformer of a column,
+// or in the columns and K values of the quasi-identifier sets.
+func (s *Schema) Compatible(other *model.Schema) error {
+	encoded, err := EncodeSchema(other)
+	if err != nil {
+		return err
+	}
+	if len(s.Columns) != len(encoded.Columns) {
+		return fmt.Errorf("incompatible schema: expected %d columns, got %d", len(s.Columns), len(encoded.Columns))
+	}
+	for i, col := range s.Columns {
+		if !reflect.DeepEqual(col, encoded.Columns[i]) {
+			return fmt.Errorf("incompatible schema: column %d (%s) differs", i, col.Name)
+		}
+	}
+	if !reflect.DeepEqual(s.QuasiIdentifiers, encoded.QuasiIdentifiers) {
+		return fmt.Errorf("incompatible schema: quasi-identifier sets differ")
+	}
+	return nil
+}
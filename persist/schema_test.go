@@ -0,0 +1,156 @@
+package persist
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+type customGeneralizer struct {
+	generalization.Suppressor
+}
+
+func getTestSchema() *model.Schema {
+	return &model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Name", nil),
+			model.NewColumn("Gender", &generalization.Suppressor{}),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 150)),
+			model.NewColumn("Index", generalization.NewFloatRangeGeneralizer(0, 1)),
+			model.NewWeightedColumn("Grade", generalization.ExampleGradeGeneralizer(), 1.2),
+			model.NewColumn("Motto", &generalization.PrefixGeneralizer{MaxWords: 10}),
+			model.NewColumn("Custom", &customGeneralizer{}),
+		},
+	}
+}
+
+func TestEncodeSchema(t *testing.T) {
+	s, err := EncodeSchema(getTestSchema())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.AssertEquals(7, len(s.Columns), t)
+	testutil.AssertEquals("Name", s.Columns[0].Name, t)
+	testutil.AssertNil(s.Columns[0].Generalizer, t)
+	testutil.AssertEquals(SuppressorGeneralizer, s.Columns[1].Generalizer.Type, t)
+	testutil.AssertEquals(IntRangeGeneralizer, s.Columns[2].Generalizer.Type, t)
+	testutil.AssertEquals(150.0, s.Columns[2].Generalizer.Max, t)
+	testutil.AssertEquals(FloatRangeGeneralizer, s.Columns[3].Generalizer.Type, t)
+	testutil.AssertEquals(HierarchyGeneralizer, s.Columns[4].Generalizer.Type, t)
+	testutil.AssertEquals(3, len(s.Columns[4].Generalizer.Hierarchy.Children), t)
+	testutil.AssertEquals(1.2, s.Columns[4].Weight, t)
+	testutil.AssertEquals(10, s.Columns[5].Generalizer.MaxWords, t)
+	testutil.AssertEquals("*persist.customGeneralizer", s.Columns[6].Generalizer.Type, t)
+}
+
+func TestEncodeSchema_SetValued(t *testing.T) {
+	s, err := EncodeSchema(&model.Schema{
+		Columns: []*model.Column{model.NewSetValuedColumn("Grades", hierarchy.GetGradeHierarchy())},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.AssertNil(s.Columns[0].Generalizer, t)
+	testutil.AssertEquals(3, len(s.Columns[0].Items.Children), t)
+}
+
+func TestEncodeSchema_Trajectory(t *testing.T) {
+	s, err := EncodeSchema(&model.Schema{
+		Columns: []*model.Column{
+			model.NewTrajectoryColumn("Route", generalization.ExampleGradeGeneralizer(), generalization.NewIntRangeGeneralizer(0, 23)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.AssertNil(s.Columns[0].Generalizer, t)
+	testutil.AssertEquals(HierarchyGeneralizer, s.Columns[0].Trajectory.Place.Type, t)
+	testutil.AssertEquals(IntRangeGeneralizer, s.Columns[0].Trajectory.Time.Type, t)
+	testutil.AssertEquals(23.0, s.Columns[0].Trajectory.Time.Max, t)
+}
+
+func TestSchema_Compatible(t *testing.T) {
+	s, _ := EncodeSchema(getTestSchema())
+
+	t.Run("same schema", func(t *testing.T) {
+		if err := s.Compatible(getTestSchema()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("different column count", func(t *testing.T) {
+		other := getTestSchema()
+		other.Columns = other.Columns[1:]
+		if err := s.Compatible(other); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("different generalizer parameters", func(t *testing.T) {
+		other := getTestSchema()
+		other.Columns[2] = model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 100))
+		err := s.Compatible(other)
+		if err == nil || !strings.Contains(err.Error(), "Age") {
+			t.Errorf("expected error for column Age, got %v", err)
+		}
+	})
+
+	t.Run("different weight", func(t *testing.T) {
+		other := getTestSchema()
+		other.Columns[1] = model.NewWeightedColumn("Gender", &generalization.Suppressor{}, 2)
+		if err := s.Compatible(other); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("different limit", func(t *testing.T) {
+		other := getTestSchema()
+		other.Columns[2].WithMaxLevel(3)
+		err := s.Compatible(other)
+		if err == nil || !strings.Contains(err.Error(), "Age") {
+			t.Errorf("expected error for column Age, got %v", err)
+		}
+		limited, _ := EncodeSchema(other)
+		testutil.AssertEquals(3, *limited.Columns[2].MaxLevel, t)
+		if err := limited.Compatible(other); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("quasi-identifier sets", func(t *testing.T) {
+		schema := getTestSchema()
+		schema.QuasiIdentifiers = []*model.QuasiIdentifier{
+			{Columns: []string{"Gender", "Age"}, K: 3},
+			{Columns: []string{"Grade"}},
+		}
+		encoded, _ := EncodeSchema(schema)
+		testutil.AssertEquals(2, len(encoded.QuasiIdentifiers), t)
+		testutil.AssertEquals("[Gender Age]", fmt.Sprint(encoded.QuasiIdentifiers[0].Columns), t)
+		testutil.AssertEquals(3, encoded.QuasiIdentifiers[0].K, t)
+		if err := encoded.Compatible(schema); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := s.Compatible(schema); err == nil {
+			t.Errorf("expected error for added quasi-identifier sets")
+		}
+
+		other := getTestSchema()
+		other.QuasiIdentifiers = []*model.QuasiIdentifier{
+			{Columns: []string{"Gender", "Age"}, K: 5},
+			{Columns: []string{"Grade"}},
+		}
+		if err := encoded.Compatible(other); err == nil {
+			t.Errorf("expected error for different K")
+		}
+
+		other.QuasiIdentifiers[0] = &model.QuasiIdentifier{Columns: []string{"Gender"}, K: 3}
+		if err := encoded.Compatible(other); err == nil {
+			t.Errorf("expected error for different columns")
+		}
+	})
+}
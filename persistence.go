@@ -0,0 +1,237 @@
+package kanon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/persist"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// StateVersion is the version of the format written by Save.
+const StateVersion = 1
+
+// savedState is the serialized form of an anonymizer.
+type savedState struct {
+	Version     int                    `json:"version"`
+	K           int                    `json:"k"`
+	Schema      *persist.Schema        `json:"schema"`
+	Rows        [][]*persist.Partition `json:"rows"`
+	Originals   [][]*persist.Partition `json:"originals,omitempty"`
+	Groups      [][]int                `json:"groups,omitempty"`
+	Forest      [][2]int64             `json:"forest,omitempty"`
+	Pending     []int                  `json:"pending,omitempty"`
+	Published   int                    `json:"published"`
+	Transformed int                    `json:"transformed"`
+	Suppressed  []int                  `json:"suppressed,omitempty"`
+}
+
+// Save writes the state of the anonymizer to w as versioned JSON: the schema with the
+// generalizer parameters, the data of the Table, the groups and the edges of the anonymization
+// forest of the previous runs, and in Incremental mode the original data of the published rows
+// and the pending rows. The cost graph is not saved, it is rebuilt from the original data by
+// the next incremental run. Runs with quasi-identifier sets record no forest.
+// The original data is saved as plain text, except for the transformed columns, which
+// are saved with their transformed values, so the saved state does not reveal the values
+// hidden by the transformers. The state can be restored with Load, and extended with new rows.
+func (a *Anonymizer) Save(w io.Writer) error {
+	schema, err := persist.EncodeSchema(a.Table.GetSchema())
+	if err != nil {
+		return err
+	}
+	s := &savedState{
+		Version:     StateVersion,
+		K:           a.K,
+		Schema:      schema,
+		Transformed: a.transformed,
+		Suppressed:  a.suppressed,
+	}
+	if s.Rows, err = encodeRows(a.Table.GetRows(), func(row *model.Row) []partition.Partition {
+		return row.Data
+	}); err != nil {
+		return err
+	}
+	if a.state != nil {
+		s.Groups = a.state.groups
+		s.Forest = a.state.forestEdges()
+		s.Pending = a.state.pending
+		s.Published = a.state.published
+		if s.Originals, err = encodeRows(a.savedOriginals(), func(data []partition.Partition) []partition.Partition {
+			return data
+		}); err != nil {
+			return err
+		}
+	}
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Load restores a state written by Save. The schema of the Table must be compatible
+// with the saved schema, the Table is replaced by a new Table with the same schema,
+// containing the saved rows. If K is not set, it is restored from the saved state.
+// The restored groups are extended by the next run, when Incremental is set, and the
+// state was saved in Incremental mode.
+func (a *Anonymizer) Load(r io.Reader) error {
+	if a.Table == nil {
+		return errors.New("the table of the anonymizer must be set to load a state")
+	}
+	s := &savedState{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return fmt.Errorf("cannot decode state: %w", err)
+	}
+	if s.Version != StateVersion {
+		return fmt.Errorf("unsupported state version: %d", s.Version)
+	}
+	if err := s.Schema.Compatible(a.Table.GetSchema()); err != nil {
+		return err
+	}
+	if a.K != 0 && a.K != s.K {
+		return fmt.Errorf("state was saved with K=%d, anonymizer has K=%d", s.K, a.K)
+	}
+	rows, err := decodeRows(s.Rows, len(s.Schema.Columns))
+	if err != nil {
+		return err
+	}
+	originals, err := decodeRows(s.Originals, len(s.Schema.Columns))
+	if err != nil {
+		return err
+	}
+	if err := s.validate(len(rows), len(originals)); err != nil {
+		return err
+	}
+	table := model.NewTable(a.Table.GetSchema())
+	for _, data := range rows {
+		table.AppendRows(&model.Row{Data: data})
+	}
+	a.Table = table
+	a.K = s.K
+	a.transformed = s.Transformed
+	a.suppressed = s.Suppressed
+	a.state = nil
+	if s.Published > 0 {
+		a.state = newRunState()
+		a.state.published = s.Published
+		a.state.groups = s.Groups
+		a.state.pending = s.Pending
+		a.state.originals = originals
+		a.state.setForest(s.Forest)
+	}
+	return nil
+}
+
+// savedOriginals returns the original data of the published rows, with the values of the
+// transformed columns replaced by their transformed values in the Table.
+func (a *Anonymizer) savedOriginals() [][]partition.Partition {
+	rows := a.Table.GetRows()
+	result := make([][]partition.Partition, len(a.state.originals))
+	for i, data := range a.state.originals {
+		result[i] = append([]partition.Partition(nil), data...)
+		for colIdx, colDef := range a.Table.GetSchema().Columns {
+			if colDef.IsTransformed() {
+				result[i][colIdx] = rows[i].Data[colIdx]
+			}
+		}
+	}
+	return result
+}
+
+// forestEdges returns the edges of the forest, sorted by their nodes.
+func (s *runState) forestEdges() [][2]int64 {
+	var edges [][2]int64
+	it := s.forest.Edges()
+	for it.Next() {
+		u, v := it.Edge().From().ID(), it.Edge().To().ID()
+		edges = append(edges, [2]int64{min(u, v), max(u, v)})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	return edges
+}
+
+// setForest restores the forest from its edges, and the grouped rows as its nodes.
+func (s *runState) setForest(edges [][2]int64) {
+	for _, group := range s.groups {
+		for _, row := range group {
+			if s.forest.Node(int64(row)) == nil {
+				s.forest.AddNode(simple.Node(row))
+			}
+		}
+	}
+	for _, edge := range edges {
+		s.forest.SetEdge(s.forest.NewEdge(simple.Node(edge[0]), simple.Node(edge[1])))
+		s.steiner = max(s.steiner, int(-edge[0]))
+	}
+}
+
+func (s *savedState) validate(rows, originals int) error {
+	if s.Transformed > rows || s.Published > rows || (originals > 0 && s.Published != originals) {
+		return errors.New("inconsistent state: row counts do not match")
+	}
+	for _, group := range s.Groups {
+		for _, idx := range group {
+			if idx < 0 || idx >= s.Published {
+				return fmt.Errorf("inconsistent state: invalid row index in group: %d", idx)
+			}
+		}
+	}
+	for _, edge := range s.Forest {
+		if edge[0] == edge[1] {
+			return fmt.Errorf("inconsistent state: loop in forest: %d", edge[0])
+		}
+		for _, id := range edge {
+			if id >= int64(s.Published) {
+				return fmt.Errorf("inconsistent state: invalid row index in forest: %d", id)
+			}
+		}
+	}
+	for _, idx := range s.Pending {
+		if idx < 0 || idx >= s.Published {
+			return fmt.Errorf("inconsistent state: invalid pending row index: %d", idx)
+		}
+	}
+	for _, idx := range s.Suppressed {
+		if idx < 0 || idx >= rows {
+			return fmt.Errorf("inconsistent state: invalid suppressed row index: %d", idx)
+		}
+	}
+	return nil
+}
+
+func encodeRows[T any](rows []T, data func(T) []partition.Partition) ([][]*persist.Partition, error) {
+	result := make([][]*persist.Partition, len(rows))
+	for i, row := range rows {
+		for _, p := range data(row) {
+			encoded, err := persist.EncodePartition(p)
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode row %d: %w", i, err)
+			}
+			result[i] = append(result[i], encoded)
+		}
+	}
+	return result, nil
+}
+
+func decodeRows(rows [][]*persist.Partition, columns int) ([][]partition.Partition, error) {
+	result := make([][]partition.Partition, len(rows))
+	for i, row := range rows {
+		if len(row) != columns {
+			return nil, fmt.Errorf("row %d has %d columns, expected %d", i, len(row), columns)
+		}
+		for _, encoded := range row {
+			p, err := encoded.Decode()
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode row %d: %w", i, err)
+			}
+			result[i] = append(result[i], p)
+		}
+	}
+	return result, nil
+}
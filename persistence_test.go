@@ -0,0 +1,245 @@
+package kanon
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+	"github.com/gar-r/k-anon/transformation"
+)
+
+func getPersistenceSchema() *model.Schema {
+	return &model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Name", nil),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 150)),
+			model.NewColumn("Grade", generalization.ExampleGradeGeneralizer()),
+			model.NewColumn("Index", generalization.NewFloatRangeGeneralizer(0, 1)),
+		},
+	}
+}
+
+func addPersistenceRows(table *model.Table, rnd *rand.Rand, n int) {
+	grades := []string{"A+", "A", "A-", "B+", "B", "B-", "C+", "C", "C-"}
+	for i := 0; i < n; i++ {
+		table.AddRow(testutil.RandString(5), rnd.Intn(100), grades[rnd.Intn(len(grades))], rnd.Float64())
+	}
+}
+
+func TestAnonymizer_SaveLoad(t *testing.T) {
+
+	t.Run("resume incremental run", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		original := &Anonymizer{
+			Table:       model.NewTable(getPersistenceSchema()),
+			K:           3,
+			Incremental: true,
+		}
+		addPersistenceRows(original.Table, rnd, 20)
+		if err := original.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := original.Save(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		resumed := &Anonymizer{
+			Table:       model.NewTable(getPersistenceSchema()),
+			Incremental: true,
+		}
+		if err := resumed.Load(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(3, resumed.K, t)
+		testutil.AssertEquals(original.Table.String(), resumed.Table.String(), t)
+		testutil.AssertEquals(fmt.Sprint(original.state.groups), fmt.Sprint(resumed.state.groups), t)
+		testutil.AssertEquals(fmt.Sprint(original.state.forestEdges()), fmt.Sprint(resumed.state.forestEdges()), t)
+		testutil.AssertEquals(len(original.state.originals), len(resumed.state.originals), t)
+		for i, data := range original.state.originals {
+			for colIdx, p := range data {
+				if !p.Equals(resumed.state.originals[i][colIdx]) {
+					t.Errorf("expected original %v, got %v", p, resumed.state.originals[i][colIdx])
+				}
+			}
+		}
+
+		extra := model.NewTable(getPersistenceSchema())
+		addPersistenceRows(extra, rnd, 10)
+		for _, anon := range []*Anonymizer{original, resumed} {
+			for _, row := range extra.GetRows() {
+				anon.Table.AppendRows(&model.Row{Data: append(row.Data[:0:0], row.Data...)})
+			}
+			anon.Rand = rand.New(rand.NewSource(2))
+			if err := anon.Anonymize(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		testutil.AssertEquals(original.Table.String(), resumed.Table.String(), t)
+		testutil.AssertEquals(fmt.Sprint(original.state.forestEdges()), fmt.Sprint(resumed.state.forestEdges()), t)
+		assertKAnonymity(resumed.Table, 3, t)
+	})
+
+	t.Run("groups and forest of a non-incremental run", func(t *testing.T) {
+		anon := &Anonymizer{Table: model.NewTable(getPersistenceSchema()), K: 3, Rand: rand.New(rand.NewSource(1))}
+		addPersistenceRows(anon.Table, rand.New(rand.NewSource(1)), 20)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := anon.Save(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resumed := &Anonymizer{Table: model.NewTable(getPersistenceSchema())}
+		if err := resumed.Load(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(20, len(flatten(resumed.state.groups)), t)
+		testutil.AssertEquals(fmt.Sprint(anon.state.groups), fmt.Sprint(resumed.state.groups), t)
+		testutil.AssertEquals(fmt.Sprint(anon.state.forestEdges()), fmt.Sprint(resumed.state.forestEdges()), t)
+		if len(resumed.state.forestEdges()) == 0 {
+			t.Errorf("forest is not saved")
+		}
+		for _, group := range resumed.state.groups {
+			assertKAnonymity(resumed.Table.Select(group), 3, t)
+		}
+	})
+
+	t.Run("transformed originals are not saved", func(t *testing.T) {
+		cipher, _ := transformation.NewFF1(make([]byte, 16), 10)
+		tr, _ := transformation.NewFPETransformer(cipher, transformation.Digits, nil)
+		schema := &model.Schema{
+			Columns: []*model.Column{
+				model.NewTransformedColumn("Card", tr),
+				model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 150)),
+			},
+		}
+		anon := &Anonymizer{Table: model.NewTable(schema), K: 2, Incremental: true}
+		anon.Table.AddRow("4111-1111-1111-1111", 25)
+		anon.Table.AddRow("5500-0000-0000-0004", 30)
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := anon.Save(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, card := range []string{"4111-1111-1111-1111", "5500-0000-0000-0004"} {
+			if strings.Contains(buf.String(), card) {
+				t.Errorf("saved state contains %v", card)
+			}
+		}
+		resumed := &Anonymizer{Table: model.NewTable(schema)}
+		if err := resumed.Load(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(anon.Table.String(), resumed.Table.String(), t)
+	})
+
+	t.Run("suppressed rows", func(t *testing.T) {
+		anon := &Anonymizer{Table: getLimitedTable(), K: 2, Suppress: true}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := anon.Save(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resumed := &Anonymizer{Table: model.NewTable(getLimitedTable().GetSchema())}
+		if err := resumed.Load(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[8]", fmt.Sprint(resumed.Suppressed()), t)
+	})
+
+	t.Run("pending rows", func(t *testing.T) {
+		anon := &Anonymizer{Table: getLimitedTable(), K: 2, Incremental: true}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := anon.Save(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resumed := &Anonymizer{Table: model.NewTable(getLimitedTable().GetSchema()), Incremental: true}
+		if err := resumed.Load(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[8]", fmt.Sprint(resumed.Pending()), t)
+		resumed.Table.AddRow(6, "C", "Judy")
+		if err := resumed.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[]", fmt.Sprint(resumed.Pending()), t)
+		assertKAnonymity(resumed.Table, 2, t)
+	})
+
+	t.Run("incompatible schema", func(t *testing.T) {
+		buf := saveTestState(t)
+		schema := getPersistenceSchema()
+		schema.Columns[1] = model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 100))
+		anon := &Anonymizer{Table: model.NewTable(schema)}
+		if err := anon.Load(buf); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("different K", func(t *testing.T) {
+		buf := saveTestState(t)
+		anon := &Anonymizer{Table: model.NewTable(getPersistenceSchema()), K: 5}
+		if err := anon.Load(buf); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		buf := saveTestState(t)
+		data := strings.Replace(buf.String(), `"version":1`, `"version":99`, 1)
+		anon := &Anonymizer{Table: model.NewTable(getPersistenceSchema())}
+		err := anon.Load(strings.NewReader(data))
+		if err == nil || !strings.Contains(err.Error(), "version") {
+			t.Errorf("expected version error, got %v", err)
+		}
+	})
+
+	t.Run("invalid forest", func(t *testing.T) {
+		buf := saveTestState(t)
+		data := strings.Replace(buf.String(), `"forest":[[`, `"forest":[[99,`, 1)
+		anon := &Anonymizer{Table: model.NewTable(getPersistenceSchema())}
+		if err := anon.Load(strings.NewReader(data)); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("invalid group", func(t *testing.T) {
+		buf := saveTestState(t)
+		data := strings.Replace(buf.String(), `"groups":[[`, `"groups":[[99,`, 1)
+		anon := &Anonymizer{Table: model.NewTable(getPersistenceSchema())}
+		if err := anon.Load(strings.NewReader(data)); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func saveTestState(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	anon := &Anonymizer{
+		Table:       model.NewTable(getPersistenceSchema()),
+		K:           2,
+		Incremental: true,
+	}
+	addPersistenceRows(anon.Table, rand.New(rand.NewSource(1)), 6)
+	if err := anon.Anonymize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := anon.Save(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf
+}
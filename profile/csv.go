@@ -0,0 +1,71 @@
+package profile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gar-r/k-anon/model"
+)
+
+// ProfileCSV profiles CSV data with the default settings of the Profiler.
+func ProfileCSV(r io.Reader) (*Report, *model.Table, error) {
+	return (&Profiler{}).ProfileCSV(r)
+}
+
+// ProfileCSV profiles CSV data, where the first record contains the column names. Besides the report,
+// it returns the data loaded into a table with the suggested schema. Values of numeric columns are
+// converted to ints and float64s, other values are kept as strings, and missing values are empty strings.
+func (p *Profiler) ProfileCSV(r io.Reader) (*Report, *model.Table, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("missing header")
+	}
+	names := records[0]
+	columns := make([][]interface{}, len(names))
+	for _, record := range records[1:] {
+		for c := range names {
+			var v interface{}
+			if s := strings.TrimSpace(record[c]); s != "" {
+				v = s
+			}
+			columns[c] = append(columns[c], v)
+		}
+	}
+	report, err := p.profile(names, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	table := model.NewTable(report.Schema)
+	for i := range records[1:] {
+		row := make([]interface{}, len(names))
+		for c, profile := range report.Columns {
+			row[c], err = convert(columns[c][i], profile.Type)
+			if err != nil {
+				return nil, nil, fmt.Errorf("record %d, column %s: %w", i+1, profile.Name, err)
+			}
+		}
+		table.AddRow(row...)
+	}
+	return report, table, nil
+}
+
+func convert(v interface{}, t ValueType) (interface{}, error) {
+	if v == nil {
+		return "", nil
+	}
+	s := v.(string)
+	switch t {
+	case Integer:
+		return strconv.Atoi(s)
+	case Float:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
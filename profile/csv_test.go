@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+const testCSV = `Name,Phone,Age,Score,City,Zip
+Alice,+36 1 234 5678,31,1.5,Budapest,01234
+Bob,+36 1 234 5679,42,2.25,Szeged,01235
+Carol,+36 1 234 5680,35,3,Budapest,
+Dave,+36 1 234 5681,51,0.5,Debrecen,02345
+`
+
+func TestProfileCSV(t *testing.T) {
+
+	t.Run("profile and load", func(t *testing.T) {
+		report, table, err := ProfileCSV(strings.NewReader(testCSV))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []struct {
+			typ  ValueType
+			role Role
+		}{
+			{Text, DirectIdentifier},
+			{Phone, DirectIdentifier},
+			{Integer, QuasiIdentifier},
+			{Float, QuasiIdentifier},
+			{Categorical, QuasiIdentifier},
+			{ZIP, QuasiIdentifier},
+		}
+		for i, e := range expected {
+			testutil.AssertEquals(e.typ, report.Columns[i].Type, t)
+			testutil.AssertEquals(e.role, report.Columns[i].Role, t)
+		}
+		testutil.AssertEquals(1, report.Columns[5].Missing, t)
+		testutil.AssertEquals(4, len(table.GetRows()), t)
+		testutil.AssertEquals(report.Schema, table.GetSchema(), t)
+		row := table.GetRows()[2]
+		testutil.AssertEquals(true, row.Data[2].Equals(partition.NewIntRange(35, 35)), t)
+		testutil.AssertEquals(true, row.Data[3].Equals(partition.NewFloatRange(3, 3)), t)
+		testutil.AssertEquals(true, row.Data[5].Equals(partition.NewSet("")), t)
+		zip := table.GetSchema().Columns[5].GetGeneralizer()
+		testutil.AssertNotNil(zip.Generalize(row.Data[5], 1), t)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		if _, _, err := ProfileCSV(strings.NewReader("")); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("invalid csv", func(t *testing.T) {
+		if _, _, err := ProfileCSV(strings.NewReader("a,b\n1\n")); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
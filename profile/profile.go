@@ -0,0 +1,413 @@
+package profile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// ValueType is the type of the values of a column, detected by the Profiler.
+type ValueType string
+
+// Value types detected by the Profiler.
+const (
+	Integer     ValueType = "integer"
+	Float       ValueType = "float"
+	Date        ValueType = "date"
+	ZIP         ValueType = "zip"
+	Email       ValueType = "email"
+	Phone       ValueType = "phone"
+	Categorical ValueType = "categorical"
+	Text        ValueType = "text"
+)
+
+// Role is the suggested role of a column in the anonymization.
+type Role string
+
+// Roles suggested by the Profiler. Direct identifiers single out individuals on their own, such as
+// names or email addresses. Quasi-identifiers can single out individuals in combination with each other.
+const (
+	DirectIdentifier Role = "direct-identifier"
+	QuasiIdentifier  Role = "quasi-identifier"
+	NonIdentifier    Role = "non-identifier"
+)
+
+// ColumnProfile describes the values of a column. Count is the number of non-missing values, Cardinality
+// is the ratio of distinct values to Count, and Uniqueness is the ratio of values occurring only once.
+// Min and Max are only set for numeric columns. Reason explains the suggested role.
+type ColumnProfile struct {
+	Name        string
+	Type        ValueType
+	Role        Role
+	Count       int
+	Missing     int
+	Distinct    int
+	Cardinality float64
+	Uniqueness  float64
+	Min         float64
+	Max         float64
+	Reason      string
+}
+
+// Report contains the profiles of the columns, and the suggested schema. In the suggested schema
+// direct identifiers are suppressed, numeric quasi-identifiers are generalized into ranges, dates,
+// ZIP codes and categories with generated hierarchies, and the other columns are non-identifiers.
+type Report struct {
+	Columns []*ColumnProfile
+	Schema  *model.Schema
+}
+
+// Profiler scans raw data, and suggests the roles and generalizers of its columns.
+// Columns of text with at most MaxCategories distinct values (defaults to 20) are categorical,
+// unless their values are unique.
+// Columns, where the ratio of unique values is at least UniqueThreshold (defaults to 0.9), are
+// direct identifiers, unless they hold numbers or dates, which are only direct identifiers when
+// their name suggests so, like "ID" or "SSN".
+type Profiler struct {
+	MaxCategories   int
+	UniqueThreshold float64
+}
+
+// Profile profiles the table with the default settings of the Profiler.
+func Profile(table *model.Table) (*Report, error) {
+	return (&Profiler{}).Profile(table)
+}
+
+// Profile profiles the columns of the table. The table should contain raw items, generalized values
+// are profiled by their string representation.
+func (p *Profiler) Profile(table *model.Table) (*Report, error) {
+	columns := make([][]interface{}, len(table.GetSchema().Columns))
+	for i, row := range table.GetRows() {
+		if len(row.Data) != len(columns) {
+			return nil, fmt.Errorf("row %d: expected %d columns, got %d", i, len(columns), len(row.Data))
+		}
+		for c, data := range row.Data {
+			columns[c] = append(columns[c], rawValue(data))
+		}
+	}
+	names := make([]string, len(columns))
+	for c, col := range table.GetSchema().Columns {
+		names[c] = col.GetName()
+	}
+	return p.profile(names, columns)
+}
+
+func (p *Profiler) profile(names []string, columns [][]interface{}) (*Report, error) {
+	report := &Report{Schema: &model.Schema{}}
+	for c, name := range names {
+		profile := p.profileColumn(name, columns[c])
+		col, err := suggestColumn(profile, columns[c])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		report.Columns = append(report.Columns, profile)
+		report.Schema.Columns = append(report.Schema.Columns, col)
+	}
+	return report, nil
+}
+
+func (p *Profiler) profileColumn(name string, values []interface{}) *ColumnProfile {
+	profile := &ColumnProfile{Name: name}
+	counts := make(map[string]int)
+	var present []string
+	for _, v := range values {
+		s := valueString(v)
+		if s == "" {
+			profile.Missing++
+			continue
+		}
+		counts[s]++
+		present = append(present, s)
+	}
+	profile.Count = len(present)
+	profile.Distinct = len(counts)
+	if profile.Count > 0 {
+		unique := 0
+		for _, n := range counts {
+			if n == 1 {
+				unique++
+			}
+		}
+		profile.Cardinality = float64(profile.Distinct) / float64(profile.Count)
+		profile.Uniqueness = float64(unique) / float64(profile.Count)
+	}
+	profile.Type = p.detectType(name, present, profile)
+	if profile.Type == Integer || profile.Type == Float {
+		for i, s := range present {
+			f, _ := strconv.ParseFloat(s, 64)
+			if i == 0 || f < profile.Min {
+				profile.Min = f
+			}
+			if i == 0 || f > profile.Max {
+				profile.Max = f
+			}
+		}
+	}
+	profile.Role, profile.Reason = p.suggestRole(name, profile)
+	return profile
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+	zipPattern   = regexp.MustCompile(`^\d{5}(-\d{4})?$`)
+	phonePattern = regexp.MustCompile(`^\+?[\d\s().-]+$`)
+)
+
+// dateLayouts are the date formats recognized by the Profiler.
+var dateLayouts = []string{"2006-01-02", "2006/01/02", "2.1.2006", "2/1/2006", "1/2/2006"}
+
+// detectType returns the first type, which matches all values of the column. Numeric columns with
+// missing values are detected as categorical or text columns, because ranges cannot hold missing values.
+func (p *Profiler) detectType(name string, values []string, profile *ColumnProfile) ValueType {
+	if len(values) == 0 {
+		return Text
+	}
+	switch {
+	case allOf(values, emailPattern.MatchString):
+		return Email
+	case allOf(values, func(s string) bool { _, ok := parseDate(s); return ok }):
+		return Date
+	case allOf(values, zipPattern.MatchString) && (hasHint(name, "zip", "postal", "postcode") ||
+		anyOf(values, func(s string) bool { return s[0] == '0' || strings.Contains(s, "-") })):
+		return ZIP
+	case profile.Missing == 0 && allOf(values, func(s string) bool { _, err := strconv.Atoi(s); return err == nil }):
+		return Integer
+	case profile.Missing == 0 && allOf(values, func(s string) bool { _, err := strconv.ParseFloat(s, 64); return err == nil }):
+		return Float
+	case allOf(values, isPhone) && anyOf(values, func(s string) bool { return strings.ContainsAny(s, "+-(). ") }):
+		return Phone
+	case profile.Distinct <= p.maxCategories() && profile.Uniqueness < p.uniqueThreshold():
+		return Categorical
+	}
+	return Text
+}
+
+func (p *Profiler) suggestRole(name string, profile *ColumnProfile) (Role, string) {
+	switch {
+	case profile.Count == 0:
+		return NonIdentifier, "no values"
+	case profile.Type == Email || profile.Type == Phone:
+		return DirectIdentifier, fmt.Sprintf("contains %s values", profile.Type)
+	case hasHint(name, "id", "ssn", "name", "email", "phone", "passport", "iban", "account", "card", "address") &&
+		profile.Uniqueness >= p.uniqueThreshold():
+		return DirectIdentifier, "name suggests an identifier, and the values are unique"
+	case profile.Distinct < 2:
+		return NonIdentifier, "constant value"
+	case profile.Type == Text && profile.Uniqueness >= p.uniqueThreshold():
+		return DirectIdentifier, fmt.Sprintf("%.0f%% of the values are unique", 100*profile.Uniqueness)
+	case profile.Type == Text:
+		return NonIdentifier, "free text with many distinct values"
+	}
+	return QuasiIdentifier, fmt.Sprintf("%s values with %d distinct values", profile.Type, profile.Distinct)
+}
+
+// suggestColumn creates the column definition of the suggested schema.
+func suggestColumn(profile *ColumnProfile, values []interface{}) (*model.Column, error) {
+	switch profile.Role {
+	case DirectIdentifier:
+		return model.NewColumn(profile.Name, &generalization.Suppressor{}), nil
+	case NonIdentifier:
+		return model.NewColumn(profile.Name, nil), nil
+	}
+	var h hierarchy.Hierarchy
+	var err error
+	switch profile.Type {
+	case Integer:
+		return model.NewColumn(profile.Name, generalization.NewIntRangeGeneralizer(int(profile.Min), int(profile.Max))), nil
+	case Float:
+		return model.NewColumn(profile.Name, generalization.NewFloatRangeGeneralizer(profile.Min, profile.Max)), nil
+	case Date:
+		h, err = pathHierarchy(values, func(s string) []string {
+			t, _ := parseDate(s)
+			return []string{t.Format("2006"), t.Format("2006-01")}
+		})
+	case ZIP:
+		h, err = pathHierarchy(values, func(s string) []string {
+			return []string{s[:1], s[:3]}
+		})
+	default:
+		items := distinctItems(values)
+		h, err = hierarchy.AutoBuild(2, items...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return model.NewColumn(profile.Name, &generalization.HierarchyGeneralizer{Hierarchy: h}), nil
+}
+
+// pathHierarchy builds a hierarchy, where the items are grouped by the keys returned by path, with one
+// level for each key. Missing values have empty keys, so they form their own group on each level.
+func pathHierarchy(values []interface{}, path func(s string) []string) (hierarchy.Hierarchy, error) {
+	items := distinctItems(values)
+	paths := make(map[interface{}][]string, len(items))
+	depth := 0
+	for _, item := range items {
+		s := valueString(item)
+		if s == "" {
+			continue
+		}
+		paths[item] = path(s)
+		depth = len(paths[item])
+	}
+	for _, item := range items {
+		if paths[item] == nil {
+			paths[item] = make([]string, depth)
+		}
+	}
+	var build func(items []interface{}, level int) []hierarchy.Hierarchy
+	build = func(items []interface{}, level int) []hierarchy.Hierarchy {
+		var result []hierarchy.Hierarchy
+		if level == depth {
+			for _, item := range items {
+				result = append(result, hierarchy.N(partition.NewSet(item)))
+			}
+			return result
+		}
+		var keys []string
+		groups := make(map[string][]interface{})
+		for _, item := range items {
+			key := paths[item][level]
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], item)
+		}
+		for _, key := range keys {
+			result = append(result, hierarchy.N(partition.NewSet(groups[key]...), build(groups[key], level+1)...))
+		}
+		return result
+	}
+	return hierarchy.Build(partition.NewSet(items...), build(items, 0)...)
+}
+
+// distinctItems returns the distinct values sorted by their string representation.
+// Missing values are represented by an empty string.
+func distinctItems(values []interface{}) []interface{} {
+	seen := make(map[string]bool)
+	var items []interface{}
+	for _, v := range values {
+		if v == nil {
+			v = ""
+		}
+		s := valueString(v)
+		if !seen[s] {
+			seen[s] = true
+			items = append(items, v)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return valueString(items[i]) < valueString(items[j])
+	})
+	return items
+}
+
+func (p *Profiler) maxCategories() int {
+	if p.MaxCategories <= 0 {
+		return 20
+	}
+	return p.MaxCategories
+}
+
+func (p *Profiler) uniqueThreshold() float64 {
+	if p.UniqueThreshold <= 0 {
+		return 0.9
+	}
+	return p.UniqueThreshold
+}
+
+// rawValue returns the item of a partition, or its string representation for generalized values.
+func rawValue(p partition.Partition) interface{} {
+	if item, ok := p.(*partition.Item); ok {
+		return item.GetItem()
+	}
+	if p == nil {
+		return nil
+	}
+	return p.String()
+}
+
+func valueString(v interface{}) string {
+	switch q := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return q.Format("2006-01-02")
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isPhone(s string) bool {
+	n := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return phonePattern.MatchString(s) && n >= 7 && n <= 15
+}
+
+// hasHint returns true, when one of the words of the column name is one of the hints.
+// Words are separated by non-letters and by case changes, so "PatientID" has the words "patient" and "id".
+func hasHint(name string, hints ...string) bool {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) || (unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1])) {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+			}
+			word = nil
+		}
+		if unicode.IsLetter(r) {
+			word = append(word, r)
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+	for _, w := range words {
+		for _, h := range hints {
+			if w == h {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allOf(values []string, fn func(s string) bool) bool {
+	for _, v := range values {
+		if !fn(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyOf(values []string, fn func(s string) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package profile
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getRawTable() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("PatientID", nil),
+			model.NewColumn("Name", nil),
+			model.NewColumn("Email", nil),
+			model.NewColumn("Age", nil),
+			model.NewColumn("Gender", nil),
+			model.NewColumn("BirthDate", nil),
+			model.NewColumn("Zip", nil),
+			model.NewColumn("Country", nil),
+			model.NewColumn("Notes", nil),
+		},
+	})
+	names := []string{"Alice", "Bob", "Carol", "Dave", "Eve", "Frank", "Grace", "Heidi", "Ivan", "Judy"}
+	notes := []string{"ok", "follow up", "ok", "ok", "call back", "ok", "follow up", "ok", "ok", "ok"}
+	for i, name := range names {
+		table.AddRow(
+			1000+i,
+			name,
+			fmt.Sprintf("%s@example.com", name),
+			30+i%3*10,
+			[]string{"F", "M"}[i%2],
+			time.Date(1980+i%3, time.Month(1+i), 1+i, 0, 0, 0, 0, time.UTC),
+			fmt.Sprintf("0%d1%d0", 2+i%2, i%3),
+			"HU",
+			fmt.Sprintf("%s %d", notes[i], i%4),
+		)
+	}
+	return table
+}
+
+func TestProfile(t *testing.T) {
+	report, err := Profile(getRawTable())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		typ      ValueType
+		role     Role
+		identity bool
+	}{
+		{"PatientID", Integer, DirectIdentifier, true},
+		{"Name", Text, DirectIdentifier, true},
+		{"Email", Email, DirectIdentifier, true},
+		{"Age", Integer, QuasiIdentifier, true},
+		{"Gender", Categorical, QuasiIdentifier, true},
+		{"BirthDate", Date, QuasiIdentifier, true},
+		{"Zip", ZIP, QuasiIdentifier, true},
+		{"Country", Categorical, NonIdentifier, false},
+		{"Notes", Categorical, QuasiIdentifier, true},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile := report.Columns[i]
+			testutil.AssertEquals(test.name, profile.Name, t)
+			testutil.AssertEquals(test.typ, profile.Type, t)
+			testutil.AssertEquals(test.role, profile.Role, t)
+			testutil.AssertEquals(test.identity, report.Schema.Columns[i].IsIdentifier(), t)
+			testutil.AssertEquals(true, profile.Reason != "", t)
+		})
+	}
+
+	t.Run("statistics", func(t *testing.T) {
+		age := report.Columns[3]
+		testutil.AssertEquals(10, age.Count, t)
+		testutil.AssertEquals(3, age.Distinct, t)
+		testutil.AssertEquals(0.3, age.Cardinality, t)
+		testutil.AssertEquals(0.0, age.Uniqueness, t)
+		testutil.AssertEquals(30.0, age.Min, t)
+		testutil.AssertEquals(50.0, age.Max, t)
+		testutil.AssertEquals(1.0, report.Columns[1].Uniqueness, t)
+	})
+
+	t.Run("suggested generalizers", func(t *testing.T) {
+		columns := report.Schema.Columns
+		testutil.AssertEquals(generalization.Generalizer(&generalization.Suppressor{}), columns[0].GetGeneralizer(), t)
+		age := columns[3].GetGeneralizer().(*generalization.RangeGeneralizer)
+		testutil.AssertEquals(true, age.Range().Equals(partition.NewIntRange(30, 50)), t)
+		birth := columns[5].GetGeneralizer().(*generalization.HierarchyGeneralizer)
+		testutil.AssertEquals(4, birth.Levels(), t)
+		day := time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+		year := birth.Generalize(partition.NewSet(day), 2).(*partition.Set)
+		testutil.AssertEquals(4, len(year.Items), t)
+		zip := columns[6].GetGeneralizer().(*generalization.HierarchyGeneralizer)
+		testutil.AssertEquals(4, zip.Levels(), t)
+		testutil.AssertEquals("[02100, 02110, 02120]", zip.Generalize(partition.NewSet("02100"), 1).String(), t)
+	})
+
+	t.Run("suggested schema can be used", func(t *testing.T) {
+		table := model.NewTable(report.Schema)
+		for _, row := range getRawTable().GetRows() {
+			items := make([]interface{}, len(row.Data))
+			for i, p := range row.Data {
+				items[i] = p.(*partition.Item).GetItem()
+			}
+			table.AddRow(items...)
+		}
+		for c, col := range report.Schema.Columns {
+			if !col.IsIdentifier() {
+				continue
+			}
+			for _, row := range table.GetRows() {
+				if col.GetGeneralizer().Generalize(row.Data[c], 1) == nil {
+					t.Errorf("column %s: cannot generalize %v", col.GetName(), row.Data[c])
+				}
+			}
+		}
+	})
+}
+
+func TestProfiler_Settings(t *testing.T) {
+	p := &Profiler{MaxCategories: 1, UniqueThreshold: 0.3}
+	report, err := p.Profile(getRawTable())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.AssertEquals(Text, report.Columns[4].Type, t)
+	testutil.AssertEquals(NonIdentifier, report.Columns[4].Role, t)
+	testutil.AssertEquals(DirectIdentifier, report.Columns[8].Role, t)
+}
+
+func TestHasHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"PatientID", true},
+		{"patient_id", true},
+		{"First Name", true},
+		{"Paid", false},
+		{"Username", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.AssertEquals(test.expected, hasHint(test.name, "id", "name"), t)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gar-r/k-anon/model"
+)
+
+// Combination describes the re-identification risk of a combination of columns. Unique is the ratio of
+// records, which no other record shares the values of the columns with, and Rare is the ratio of records,
+// which share them with less than the rare threshold of records, including the unique ones. Minimal is
+// set for minimal unique column combinations: each record is unique on the columns, but not on any of
+// their subsets.
+type Combination struct {
+	Columns []string
+	Unique  float64
+	Rare    float64
+	Minimal bool
+}
+
+// RiskAnalysis computes the uniqueness of the records in the combinations of the Columns (defaults to
+// all columns) up to MaxSize columns (defaults to 3). Records sharing their values with less than Rare
+// records are rare (defaults to 5, typically the K of the anonymization).
+//
+// The combinations are enumerated level-wise as in the Apriori based discovery of minimal unique column
+// combinations (see Abedjan and Naumann: "Advancing the Discovery of Unique Column Combinations"). When
+// every record is unique on a combination, it is a minimal unique combination, and its supersets are not
+// analyzed, as every record is unique on them as well. The supersets are also skipped, when one of their
+// subsets was skipped.
+type RiskAnalysis struct {
+	Columns []string
+	MaxSize int
+	Rare    int
+}
+
+// Analyze returns the analyzed combinations, ranked by the ratio of unique records, then by the ratio
+// of rare records in descending order. Of equally risky combinations the smaller ones come first, as
+// they are easier to link with other data sources.
+func (a *RiskAnalysis) Analyze(table *model.Table) ([]*Combination, error) {
+	columns, err := a.columns(table.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]int, len(columns))
+	for i, c := range columns {
+		values[i] = encodeColumn(table, c)
+	}
+	n := len(table.GetRows())
+	var result []*Combination
+	var level [][]int
+	for i := range columns {
+		level = append(level, []int{i})
+	}
+	for size := 1; size <= a.maxSize() && len(level) > 0; size++ {
+		var extendable [][]int
+		for _, combination := range level {
+			c := a.analyze(values, combination, n)
+			for _, i := range combination {
+				c.Columns = append(c.Columns, table.GetSchema().Columns[columns[i]].GetName())
+			}
+			result = append(result, c)
+			if !c.Minimal {
+				extendable = append(extendable, combination)
+			}
+		}
+		level = nextLevel(extendable)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ci, cj := result[i], result[j]
+		if ci.Unique != cj.Unique {
+			return ci.Unique > cj.Unique
+		}
+		if ci.Rare != cj.Rare {
+			return ci.Rare > cj.Rare
+		}
+		return len(ci.Columns) < len(cj.Columns)
+	})
+	return result, nil
+}
+
+func (a *RiskAnalysis) analyze(values [][]int, combination []int, n int) *Combination {
+	counts := make(map[string]int)
+	keys := make([]string, n)
+	for row := range keys {
+		var sb strings.Builder
+		for _, i := range combination {
+			sb.WriteString(strconv.Itoa(values[i][row]))
+			sb.WriteString(",")
+		}
+		keys[row] = sb.String()
+		counts[keys[row]]++
+	}
+	unique, rare := 0, 0
+	for _, key := range keys {
+		if counts[key] == 1 {
+			unique++
+		}
+		if counts[key] < a.rare() {
+			rare++
+		}
+	}
+	c := &Combination{}
+	if n > 0 {
+		c.Unique = float64(unique) / float64(n)
+		c.Rare = float64(rare) / float64(n)
+	}
+	c.Minimal = n > 0 && unique == n
+	return c
+}
+
+// nextLevel generates the combinations of the next size from the combinations of the current size, which
+// share all but their last column, keeping only the ones where each subset is in the current level.
+func nextLevel(level [][]int) [][]int {
+	present := make(map[string]bool, len(level))
+	for _, combination := range level {
+		present[fmt.Sprint(combination)] = true
+	}
+	var result [][]int
+	for i, c1 := range level {
+		for _, c2 := range level[i+1:] {
+			k := len(c1)
+			if fmt.Sprint(c1[:k-1]) != fmt.Sprint(c2[:k-1]) {
+				continue
+			}
+			candidate := append(append([]int{}, c1...), c2[k-1])
+			if allSubsetsPresent(candidate, present) {
+				result = append(result, candidate)
+			}
+		}
+	}
+	return result
+}
+
+func allSubsetsPresent(candidate []int, present map[string]bool) bool {
+	for skip := range candidate {
+		subset := make([]int, 0, len(candidate)-1)
+		subset = append(subset, candidate[:skip]...)
+		subset = append(subset, candidate[skip+1:]...)
+		if !present[fmt.Sprint(subset)] {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeColumn replaces the values of the column with integers, equal values getting the same integer.
+func encodeColumn(table *model.Table, c int) []int {
+	ids := make(map[string]int)
+	result := make([]int, len(table.GetRows()))
+	for row, data := range table.GetRows() {
+		s := data.Data[c].String()
+		id, ok := ids[s]
+		if !ok {
+			id = len(ids)
+			ids[s] = id
+		}
+		result[row] = id
+	}
+	return result
+}
+
+// columns returns the indices of the analyzed columns in ascending order.
+func (a *RiskAnalysis) columns(schema *model.Schema) ([]int, error) {
+	var result []int
+	if len(a.Columns) == 0 {
+		for i := range schema.Columns {
+			result = append(result, i)
+		}
+		return result, nil
+	}
+	for _, name := range a.Columns {
+		i := schema.IndexOf(name)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+		result = append(result, i)
+	}
+	sort.Ints(result)
+	for i := 1; i < len(result); i++ {
+		if result[i] == result[i-1] {
+			return nil, fmt.Errorf("duplicate column: %s", schema.Columns[result[i]].GetName())
+		}
+	}
+	return result, nil
+}
+
+func (a *RiskAnalysis) maxSize() int {
+	if a.MaxSize <= 0 {
+		return 3
+	}
+	return a.MaxSize
+}
+
+func (a *RiskAnalysis) rare() int {
+	if a.Rare <= 0 {
+		return 5
+	}
+	return a.Rare
+}
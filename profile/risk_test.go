@@ -0,0 +1,111 @@
+package profile
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getRiskTable() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("ID", nil),
+			model.NewColumn("Gender", nil),
+			model.NewColumn("Age", nil),
+			model.NewColumn("Country", nil),
+		},
+	})
+	table.AddRow(1, "F", 30, "HU")
+	table.AddRow(2, "M", 30, "HU")
+	table.AddRow(3, "F", 40, "HU")
+	table.AddRow(4, "M", 40, "HU")
+	table.AddRow(5, "F", 50, "HU")
+	table.AddRow(6, "F", 30, "HU")
+	return table
+}
+
+func TestRiskAnalysis_Analyze(t *testing.T) {
+
+	t.Run("ranked combinations", func(t *testing.T) {
+		result, err := (&RiskAnalysis{Rare: 2}).Analyze(getRiskTable())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		byName := make(map[string]*Combination)
+		for _, c := range result {
+			byName[fmt.Sprint(c.Columns)] = c
+		}
+		testutil.AssertEquals("[ID]", fmt.Sprint(result[0].Columns), t)
+		testutil.AssertEquals(true, result[0].Minimal, t)
+		testutil.AssertEquals(1.0, result[0].Unique, t)
+
+		ga := byName["[Gender Age]"]
+		testutil.AssertEquals(4.0/6, ga.Unique, t)
+		testutil.AssertEquals(4.0/6, ga.Rare, t)
+		testutil.AssertEquals(false, ga.Minimal, t)
+
+		age := byName["[Age]"]
+		testutil.AssertEquals(1.0/6, age.Unique, t)
+		testutil.AssertEquals(1.0/6, age.Rare, t)
+
+		country := byName["[Country]"]
+		testutil.AssertEquals(0.0, country.Unique, t)
+		testutil.AssertEquals(0.0, result[len(result)-1].Unique, t)
+
+		for i := 1; i < len(result); i++ {
+			if result[i].Unique > result[i-1].Unique {
+				t.Errorf("combinations are not ranked: %v before %v", result[i-1].Columns, result[i].Columns)
+			}
+		}
+	})
+
+	t.Run("supersets of unique combinations are pruned", func(t *testing.T) {
+		result, err := (&RiskAnalysis{}).Analyze(getRiskTable())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, c := range result {
+			if len(c.Columns) > 1 && c.Columns[0] == "ID" {
+				t.Errorf("superset of a unique combination analyzed: %v", c.Columns)
+			}
+		}
+		// 4 single columns, 3 pairs and 1 triple without ID
+		testutil.AssertEquals(8, len(result), t)
+	})
+
+	t.Run("size limit and selected columns", func(t *testing.T) {
+		result, err := (&RiskAnalysis{Columns: []string{"Age", "Gender"}, MaxSize: 1}).Analyze(getRiskTable())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(2, len(result), t)
+	})
+
+	t.Run("minimal unique combination of several columns", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: []*model.Column{model.NewColumn("Gender", nil), model.NewColumn("Age", nil)},
+		})
+		table.AddRow("F", 30)
+		table.AddRow("M", 30)
+		table.AddRow("F", 40)
+		table.AddRow("M", 40)
+		result, err := (&RiskAnalysis{}).Analyze(table)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[Gender Age]", fmt.Sprint(result[0].Columns), t)
+		testutil.AssertEquals(true, result[0].Minimal, t)
+		testutil.AssertEquals(false, result[1].Minimal, t)
+		testutil.AssertEquals(1.0, result[1].Rare, t)
+	})
+
+	t.Run("invalid columns", func(t *testing.T) {
+		for _, columns := range [][]string{{"Missing"}, {"Age", "Age"}} {
+			if _, err := (&RiskAnalysis{Columns: columns}).Analyze(getRiskTable()); err == nil {
+				t.Errorf("expected error for %v", columns)
+			}
+		}
+	})
+}
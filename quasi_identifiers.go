@@ -0,0 +1,212 @@
+package kanon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/model"
+)
+
+// anonymizeQuasiIdentifiers anonymizes the Table, so each quasi-identifier set of the schema
+// is K-anonymous with its own K:
+//   - the sets are anonymized one by one with the graph algorithm, the one with the largest K
+//     first, generalizing only the columns of the set
+//   - generalizing the columns of a set may split the groups of an earlier set sharing those
+//     columns, so the groups of each set with less than K rows are merged into the cheapest
+//     other group of the set, until all sets are K-anonymous
+//
+// Rows, which cannot be merged within the generalization limits of the columns, are blocking.
+// The groups of the run are the rows, which are identical in the columns of all sets.
+// The Table is left unchanged, when an error is returned.
+func (a *Anonymizer) anonymizeQuasiIdentifiers(ctx context.Context) (err error) {
+	sets, err := a.quasiIdentifiers()
+	if err != nil {
+		return err
+	}
+	originals := a.copyRows(0)
+	suppressed := len(a.suppressed)
+	defer func() {
+		if err != nil {
+			for i, row := range a.Table.GetRows() {
+				copy(row.Data, originals[i])
+			}
+			a.suppressed = a.suppressed[:suppressed]
+		}
+	}()
+	rows := a.unsuppressed()
+	for _, set := range sets {
+		r := a.restrict(set)
+		blocks, small := r.blocks(rows)
+		blocked := flatten(small)
+		if err := a.checkBlocked(blocked); err != nil {
+			return err
+		}
+		if _, err := r.anonymizeBlocks(ctx, blocks); err != nil {
+			return err
+		}
+		rows = a.suppressRows(rows, blocked)
+	}
+	for merged := true; merged; {
+		merged = false
+		for _, set := range sets {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("anonymization interrupted: %w", err)
+			}
+			r := a.restrict(set)
+			var blocked []int
+			if merged, blocked, err = r.mergeSmallest(rows); err != nil {
+				return err
+			}
+			if err := a.checkBlocked(blocked); err != nil {
+				return err
+			}
+			rows = a.suppressRows(rows, blocked)
+			if merged || len(blocked) > 0 {
+				merged = true
+				break
+			}
+		}
+	}
+	var columns []string
+	for _, set := range sets {
+		columns = append(columns, set.Columns...)
+	}
+	state := newRunState()
+	state.published = len(a.Table.GetRows())
+	state.groups = a.restrict(&model.QuasiIdentifier{Columns: columns}).identicalGroups(rows)
+	a.state = state
+	return nil
+}
+
+// quasiIdentifiers validates the quasi-identifier sets, and returns them with the largest K first.
+func (a *Anonymizer) quasiIdentifiers() ([]*model.QuasiIdentifier, error) {
+	if a.Incremental {
+		return nil, errors.New("quasi-identifier sets are not supported in incremental mode")
+	}
+	schema := a.Table.GetSchema()
+	var sets []*model.QuasiIdentifier
+	for i, qi := range schema.QuasiIdentifiers {
+		set := &model.QuasiIdentifier{Columns: qi.Columns, K: qi.K}
+		if set.K == 0 {
+			set.K = a.K
+		}
+		if set.K < 1 {
+			return nil, fmt.Errorf("invalid value for K of quasi-identifier set %d: %d", i, set.K)
+		}
+		if set.K > len(a.Table.GetRows()) {
+			return nil, fmt.Errorf("quasi-identifier set %d requires K=%d, but the table has %d rows", i, set.K, len(a.Table.GetRows()))
+		}
+		for _, name := range set.Columns {
+			colIdx := schema.IndexOf(name)
+			if colIdx < 0 || !schema.Columns[colIdx].IsIdentifier() {
+				return nil, fmt.Errorf("quasi-identifier set %d: %s is not an identifier column", i, name)
+			}
+		}
+		sets = append(sets, set)
+	}
+	sort.SliceStable(sets, func(i, j int) bool {
+		return sets[i].K > sets[j].K
+	})
+	return sets, nil
+}
+
+// restrict returns an anonymizer for the columns of a quasi-identifier set, sharing the rows of the Table.
+func (a *Anonymizer) restrict(set *model.QuasiIdentifier) *Anonymizer {
+	table := model.NewTable(a.Table.GetSchema().Restrict(set.Columns))
+	table.AppendRows(a.Table.GetRows()...)
+	return &Anonymizer{
+		K:          set.K,
+		Table:      table,
+		Observer:   a.Observer,
+		Workers:    a.Workers,
+		Neighbours: a.Neighbours,
+		Rand:       a.Rand,
+		Cost:       a.Cost,
+		Suppress:   a.Suppress,
+	}
+}
+
+// mergeSmallest finds the smallest group of identical rows with less than K rows, merges it
+// into the group with the lowest generalization cost, and generalizes the merged group.
+// If the group cannot be merged within the generalization limits, its rows are returned
+// as blocking rows instead.
+func (a *Anonymizer) mergeSmallest(rows []int) (bool, []int, error) {
+	groups := a.identicalGroups(rows)
+	smallest := -1
+	for i, group := range groups {
+		if len(group) < a.K && (smallest < 0 || len(group) < len(groups[smallest])) {
+			smallest = i
+		}
+	}
+	if smallest < 0 {
+		return false, nil, nil
+	}
+	group := groups[smallest]
+	best := -1
+	bestCost := math.Inf(1)
+	for i, other := range groups {
+		if i == smallest {
+			continue
+		}
+		cost, err := algorithm.CalculateCostWith(a.Table.GetRows()[group[0]], a.Table.GetRows()[other[0]], a.Table.GetSchema(), a.cost())
+		if err != nil {
+			return false, nil, err
+		}
+		if cost < bestCost {
+			best, bestCost = i, cost
+		}
+	}
+	if best < 0 {
+		return false, group, nil
+	}
+	a.generalize([][]int{append(append([]int(nil), group...), groups[best]...)})
+	return true, nil, nil
+}
+
+// identicalGroups groups the rows by their partitions in the identifier columns.
+func (a *Anonymizer) identicalGroups(rows []int) [][]int {
+	var keys []string
+	groups := make(map[string][]int)
+	for _, rowIdx := range rows {
+		key := &strings.Builder{}
+		for colIdx, col := range a.Table.GetSchema().Columns {
+			if col.IsIdentifier() {
+				key.WriteString(a.Table.GetRows()[rowIdx].Data[colIdx].String())
+				key.WriteString("\t")
+			}
+		}
+		if _, ok := groups[key.String()]; !ok {
+			keys = append(keys, key.String())
+		}
+		groups[key.String()] = append(groups[key.String()], rowIdx)
+	}
+	result := make([][]int, len(keys))
+	for i, key := range keys {
+		result[i] = groups[key]
+	}
+	return result
+}
+
+// suppressRows suppresses the blocked rows, and returns the remaining rows.
+func (a *Anonymizer) suppressRows(rows, blocked []int) []int {
+	if len(blocked) == 0 {
+		return rows
+	}
+	a.suppress(blocked)
+	skip := make(map[int]bool, len(blocked))
+	for _, rowIdx := range blocked {
+		skip[rowIdx] = true
+	}
+	var remaining []int
+	for _, rowIdx := range rows {
+		if !skip[rowIdx] {
+			remaining = append(remaining, rowIdx)
+		}
+	}
+	return remaining
+}
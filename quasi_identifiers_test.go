@@ -0,0 +1,125 @@
+package kanon
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getQuasiIdentifierTable(rnd *rand.Rand, n int, sets ...*model.QuasiIdentifier) *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Name", nil),
+			model.NewColumn("Zip", generalization.NewIntRangeGeneralizer(1000, 9999)),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 99)),
+			model.NewColumn("Gender", &generalization.Suppressor{}),
+			model.NewColumn("Diagnosed", generalization.NewIntRangeGeneralizer(1, 365)),
+			model.NewColumn("Income", generalization.NewIntRangeGeneralizer(0, 200000)),
+		},
+		QuasiIdentifiers: sets,
+	})
+	for i := 0; i < n; i++ {
+		table.AddRow(testutil.RandString(5), 1000+rnd.Intn(9000), rnd.Intn(100),
+			[]string{"male", "female"}[rnd.Intn(2)], 1+rnd.Intn(365), rnd.Intn(200001))
+	}
+	return table
+}
+
+func TestAnonymizer_QuasiIdentifiers(t *testing.T) {
+	marketing := &model.QuasiIdentifier{Columns: []string{"Zip", "Age"}, K: 4}
+	research := &model.QuasiIdentifier{Columns: []string{"Gender", "Diagnosed"}}
+
+	t.Run("each set is k-anonymous", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(3))
+		table := getQuasiIdentifierTable(rnd, 60, marketing, research)
+		incomes := make([]string, len(table.GetRows()))
+		for i, row := range table.GetRows() {
+			incomes[i] = row.Data[5].String()
+		}
+		anon := &Anonymizer{Table: table, K: 2, Rand: rand.New(rand.NewSource(1))}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertQuasiIdentifier(table, marketing.Columns, 4, t)
+		assertQuasiIdentifier(table, research.Columns, 2, t)
+		for i, row := range table.GetRows() {
+			testutil.AssertEquals(incomes[i], row.Data[5].String(), t)
+		}
+	})
+
+	t.Run("overlapping sets", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(5))
+		sets := []*model.QuasiIdentifier{
+			{Columns: []string{"Zip", "Age"}, K: 3},
+			{Columns: []string{"Age", "Gender"}, K: 5},
+			{Columns: []string{"Zip", "Diagnosed"}, K: 2},
+		}
+		table := getQuasiIdentifierTable(rnd, 50, sets...)
+		anon := &Anonymizer{Table: table, Rand: rand.New(rand.NewSource(1))}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, set := range sets {
+			assertQuasiIdentifier(table, set.Columns, set.K, t)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		table := getQuasiIdentifierTable(rand.New(rand.NewSource(1)), 10,
+			&model.QuasiIdentifier{Columns: []string{"Name"}, K: 2})
+		err := (&Anonymizer{Table: table, K: 2}).Anonymize()
+		if err == nil || !strings.Contains(err.Error(), "Name") {
+			t.Errorf("expected error for column Name, got %v", err)
+		}
+	})
+
+	t.Run("too few rows", func(t *testing.T) {
+		table := getQuasiIdentifierTable(rand.New(rand.NewSource(1)), 3, marketing)
+		err := (&Anonymizer{Table: table, K: 2}).Anonymize()
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		table := getQuasiIdentifierTable(rand.New(rand.NewSource(1)), 10, marketing)
+		err := (&Anonymizer{Table: table, K: 2, Incremental: true}).Anonymize()
+		testutil.AssertNotNil(err, t)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		table := getQuasiIdentifierTable(rand.New(rand.NewSource(1)), 20, marketing, research)
+		before := table.String()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := (&Anonymizer{Table: table, K: 2}).AnonymizeContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected cancellation, got %v", err)
+		}
+		testutil.AssertEquals(before, table.String(), t)
+	})
+}
+
+// assertQuasiIdentifier checks that each combination of the values in the given columns
+// occurs at least k times in the table.
+func assertQuasiIdentifier(table *model.Table, columns []string, k int, t *testing.T) {
+	t.Helper()
+	counts := make(map[string]int)
+	for _, row := range table.GetRows() {
+		key := &strings.Builder{}
+		for _, name := range columns {
+			key.WriteString(row.Data[table.GetSchema().IndexOf(name)].String())
+			key.WriteString("\t")
+		}
+		counts[key.String()]++
+	}
+	for key, count := range counts {
+		if count < k {
+			t.Errorf("columns %v: %q occurs %d times, expected at least %d", columns, key, count, k)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// RelationalAnonymizer anonymizes a table of individuals together with its child tables, so that
+// K counts individuals instead of rows. Each individual is identified by the value of the Key column
+// of the Table, and may have several rows in the Table, and any number of rows in the child tables,
+// which refer to it with their foreign key column. The released rows of each individual are
+// indistinguishable from the rows of at least K-1 other individuals (MultiR k-anonymity, see Nergiz,
+// Clifton and Nergiz: "Multirelational k-Anonymity").
+//
+// The individuals are clustered with greedy k-member clustering. In each cluster the rows of an
+// individual in a table are paired with the rows of the other members, and the identifier columns of the
+// paired rows are generalized into common partitions. The number of rows in a table is aligned to the
+// member with the least rows, and the remaining rows are suppressed: their identifier columns and their
+// key are replaced with "*", so they cannot be linked to any individual. The key columns should hold
+// pseudonyms, and should not be identifier columns. Generalization limits of the columns are not applied.
+type RelationalAnonymizer struct {
+	K        int
+	Table    *model.Table
+	Key      string
+	Children []*ChildTable
+
+	suppressed map[*model.Table][]int
+}
+
+// ChildTable is a table, which refers to the individuals of the RelationalAnonymizer with the ForeignKey column.
+type ChildTable struct {
+	Table      *model.Table
+	ForeignKey string
+}
+
+// profile holds the rows of an individual in each table, the first table being the table of the individuals.
+type profile [][][]partition.Partition
+
+// Anonymize generalizes the rows of the table and the child tables in place.
+func (a *RelationalAnonymizer) Anonymize() error {
+	tables, keys, err := a.validate()
+	if err != nil {
+		return err
+	}
+	individuals, records, err := a.collect(tables, keys)
+	if err != nil {
+		return err
+	}
+	if len(individuals) < a.K {
+		return fmt.Errorf("cannot anonymize %d individuals with K=%d", len(individuals), a.K)
+	}
+	profiles := make([]profile, len(individuals))
+	for i := range profiles {
+		profiles[i] = make(profile, len(tables))
+		for t, table := range tables {
+			for _, rowIdx := range records[t][i] {
+				profiles[i][t] = append(profiles[i][t], table.GetRows()[rowIdx].Data)
+			}
+		}
+	}
+	merge := func(p ...profile) (profile, float64, error) {
+		return mergeProfiles(tables, p...)
+	}
+	clusters, err := kMemberClustering(profiles, a.K, merge)
+	if err != nil {
+		return err
+	}
+	a.suppressed = make(map[*model.Table][]int)
+	for _, cluster := range clusters {
+		members := make([]profile, len(cluster))
+		for i, idx := range cluster {
+			members[i] = profiles[idx]
+		}
+		merged, _, err := merge(members...)
+		if err != nil {
+			return err
+		}
+		for _, idx := range cluster {
+			for t, table := range tables {
+				for j, rowIdx := range records[t][idx] {
+					if j < len(merged[t]) {
+						generalizeRow(table, rowIdx, merged[t][j])
+					} else {
+						a.suppressRow(table, rowIdx, keys[t])
+					}
+				}
+			}
+		}
+	}
+	for _, rows := range a.suppressed {
+		sort.Ints(rows)
+	}
+	return nil
+}
+
+// Suppressed returns the indices of the rows of the given table, which were suppressed by the last run.
+func (a *RelationalAnonymizer) Suppressed(table *model.Table) []int {
+	return a.suppressed[table]
+}
+
+// validate returns the tables starting with the table of the individuals, and the index of their key columns.
+func (a *RelationalAnonymizer) validate() ([]*model.Table, []int, error) {
+	if a.K < 1 {
+		return nil, nil, fmt.Errorf("invalid value for K: %d", a.K)
+	}
+	if a.Table == nil {
+		return nil, nil, errors.New("missing table")
+	}
+	tables := []*model.Table{a.Table}
+	keys := []int{a.Table.GetSchema().IndexOf(a.Key)}
+	if keys[0] < 0 {
+		return nil, nil, fmt.Errorf("unknown key column: %s", a.Key)
+	}
+	for i, child := range a.Children {
+		if child == nil || child.Table == nil {
+			return nil, nil, fmt.Errorf("child table %d: missing table", i)
+		}
+		key := child.Table.GetSchema().IndexOf(child.ForeignKey)
+		if key < 0 {
+			return nil, nil, fmt.Errorf("child table %d: unknown foreign key column: %s", i, child.ForeignKey)
+		}
+		tables = append(tables, child.Table)
+		keys = append(keys, key)
+	}
+	return tables, keys, nil
+}
+
+// collect returns the key of each individual in order of appearance in the table, and the indices of
+// the rows of each individual in each table. The rows of an individual are ordered by their identifier
+// columns, so similar rows are paired with each other.
+func (a *RelationalAnonymizer) collect(tables []*model.Table, keys []int) ([]string, [][][]int, error) {
+	var individuals []string
+	index := make(map[string]int)
+	for _, row := range a.Table.GetRows() {
+		key := row.Data[keys[0]].String()
+		if _, ok := index[key]; !ok {
+			index[key] = len(individuals)
+			individuals = append(individuals, key)
+		}
+	}
+	records := make([][][]int, len(tables))
+	for t, table := range tables {
+		records[t] = make([][]int, len(individuals))
+		for rowIdx, row := range table.GetRows() {
+			key := row.Data[keys[t]].String()
+			i, ok := index[key]
+			if !ok {
+				return nil, nil, fmt.Errorf("table %d, row %d: unknown individual: %s", t, rowIdx, key)
+			}
+			records[t][i] = append(records[t][i], rowIdx)
+		}
+		for _, rows := range records[t] {
+			sort.SliceStable(rows, func(i, j int) bool {
+				return rowKey(table, rows[i]) < rowKey(table, rows[j])
+			})
+		}
+	}
+	return individuals, records, nil
+}
+
+// mergeProfiles generalizes the profiles into a single profile, which covers each of them after aligning
+// the number of rows in each table to the profile with the least rows. It also returns the information
+// loss between 0 and 1, which is the average weighted generalization level of the identifier columns,
+// where the rows removed by the alignment count as fully generalized.
+func mergeProfiles(tables []*model.Table, profiles ...profile) (profile, float64, error) {
+	result := make(profile, len(tables))
+	loss, total := 0.0, 0
+	for t, table := range tables {
+		count := len(profiles[0][t])
+		for _, p := range profiles {
+			count = min(count, len(p[t]))
+			total += len(p[t])
+			loss += float64(len(p[t]))
+		}
+		loss -= float64(count * len(profiles))
+		result[t] = make([][]partition.Partition, count)
+		for j := range result[t] {
+			rows := make([][]partition.Partition, len(profiles))
+			for i, p := range profiles {
+				rows[i] = p[t][j]
+			}
+			row, rowLoss, err := mergeRows(table.GetSchema(), rows)
+			if err != nil {
+				return nil, 0, err
+			}
+			result[t][j] = row
+			loss += rowLoss * float64(len(profiles))
+		}
+	}
+	if total == 0 {
+		return result, 0, nil
+	}
+	return result, loss / float64(total), nil
+}
+
+// mergeRows generalizes each identifier column of the rows into their common partition.
+// Non-identifier columns are taken from the first row.
+func mergeRows(schema *model.Schema, rows [][]partition.Partition) ([]partition.Partition, float64, error) {
+	result := make([]partition.Partition, len(rows[0]))
+	copy(result, rows[0])
+	loss, weights := 0.0, 0.0
+	for c, col := range schema.Columns {
+		if !col.IsIdentifier() {
+			continue
+		}
+		partitions := make([]partition.Partition, len(rows))
+		for i, row := range rows {
+			partitions[i] = row[c]
+		}
+		p, level, err := commonGeneralization(col.GetGeneralizer(), partitions)
+		if err != nil {
+			return nil, 0, fmt.Errorf("column %s: %w", col.GetName(), err)
+		}
+		result[c] = p
+		loss += col.GetWeight() * levelLoss(col.GetGeneralizer(), level)
+		weights += col.GetWeight()
+	}
+	if weights == 0 {
+		return result, 0, nil
+	}
+	return result, loss / weights, nil
+}
+
+// generalizeRow replaces the identifier columns of the row with the columns of the generalized row.
+func generalizeRow(table *model.Table, rowIdx int, generalized []partition.Partition) {
+	row := table.GetRows()[rowIdx]
+	for c, col := range table.GetSchema().Columns {
+		if col.IsIdentifier() {
+			row.Data[c] = generalized[c]
+		}
+	}
+}
+
+func (a *RelationalAnonymizer) suppressRow(table *model.Table, rowIdx, key int) {
+	row := table.GetRows()[rowIdx]
+	for c, col := range table.GetSchema().Columns {
+		if col.IsIdentifier() || c == key {
+			row.Data[c] = partition.NewItem("*")
+		}
+	}
+	a.suppressed[table] = append(a.suppressed[table], rowIdx)
+}
+
+func rowKey(table *model.Table, rowIdx int) string {
+	var sb strings.Builder
+	for c, col := range table.GetSchema().Columns {
+		if col.IsIdentifier() {
+			sb.WriteString(table.GetRows()[rowIdx].Data[c].String())
+			sb.WriteString("\x00")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,153 @@
+package kanon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getPatientTables() (*model.Table, *model.Table) {
+	patients := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("ID", nil),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 99)),
+			model.NewColumn("Grade", generalization.ExampleGradeGeneralizer()),
+		},
+	})
+	patients.AddRow("p1", 31, "A")
+	patients.AddRow("p2", 35, "A-")
+	patients.AddRow("p3", 62, "C")
+	patients.AddRow("p4", 67, "C+")
+	patients.AddRow("p5", 33, "B")
+	patients.AddRow("p6", 64, "C-")
+	visits := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("PatientID", nil),
+			model.NewColumn("Day", generalization.NewIntRangeGeneralizer(0, 99)),
+			model.NewColumn("Diagnosis", nil),
+		},
+	})
+	visits.AddRow("p1", 10, "flu")
+	visits.AddRow("p1", 40, "cold")
+	visits.AddRow("p2", 12, "flu")
+	visits.AddRow("p2", 45, "asthma")
+	visits.AddRow("p3", 80, "diabetes")
+	visits.AddRow("p4", 82, "diabetes")
+	visits.AddRow("p4", 90, "gout")
+	visits.AddRow("p4", 95, "gout")
+	visits.AddRow("p5", 15, "cold")
+	visits.AddRow("p5", 42, "flu")
+	visits.AddRow("p6", 85, "diabetes")
+	return patients, visits
+}
+
+func TestRelationalAnonymizer_Anonymize(t *testing.T) {
+
+	t.Run("k-anonymity of individuals", func(t *testing.T) {
+		for _, k := range []int{1, 2, 3, 6} {
+			patients, visits := getPatientTables()
+			anon := &RelationalAnonymizer{
+				K:        k,
+				Table:    patients,
+				Key:      "ID",
+				Children: []*ChildTable{{Table: visits, ForeignKey: "PatientID"}},
+			}
+			if err := anon.Anonymize(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertIndividualAnonymity([]*model.Table{patients, visits}, []int{0, 0}, k, t)
+		}
+	})
+
+	t.Run("extra rows are suppressed", func(t *testing.T) {
+		patients, visits := getPatientTables()
+		anon := &RelationalAnonymizer{
+			K:        3,
+			Table:    patients,
+			Key:      "ID",
+			Children: []*ChildTable{{Table: visits, ForeignKey: "PatientID"}},
+		}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("[6 7]", fmt.Sprint(anon.Suppressed(visits)), t)
+		testutil.AssertEquals(0, len(anon.Suppressed(patients)), t)
+		row := visits.GetRows()[6]
+		testutil.AssertEquals("*", row.Data[0].String(), t)
+		testutil.AssertEquals("*", row.Data[1].String(), t)
+		testutil.AssertEquals("gout", row.Data[2].String(), t)
+	})
+
+	t.Run("multiple rows per individual", func(t *testing.T) {
+		_, visits := getPatientTables()
+		anon := &RelationalAnonymizer{K: 2, Table: visits, Key: "PatientID"}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertIndividualAnonymity([]*model.Table{visits}, []int{0}, 2, t)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		patients, visits := getPatientTables()
+		orphan, _ := getPatientTables()
+		orphan.AddRow("p7", 50, "B")
+		tests := []struct {
+			name string
+			anon *RelationalAnonymizer
+		}{
+			{"invalid K", &RelationalAnonymizer{K: 0, Table: patients, Key: "ID"}},
+			{"missing table", &RelationalAnonymizer{K: 2, Key: "ID"}},
+			{"unknown key", &RelationalAnonymizer{K: 2, Table: patients, Key: "Missing"}},
+			{"missing child table", &RelationalAnonymizer{K: 2, Table: patients, Key: "ID", Children: []*ChildTable{{ForeignKey: "PatientID"}}}},
+			{"unknown foreign key", &RelationalAnonymizer{K: 2, Table: patients, Key: "ID", Children: []*ChildTable{{Table: visits, ForeignKey: "Missing"}}}},
+			{"unknown individual", &RelationalAnonymizer{K: 2, Table: visits, Key: "PatientID", Children: []*ChildTable{{Table: orphan, ForeignKey: "ID"}}}},
+			{"too few individuals", &RelationalAnonymizer{K: 7, Table: patients, Key: "ID"}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if err := test.anon.Anonymize(); err == nil {
+					t.Errorf("expected error")
+				}
+			})
+		}
+	})
+}
+
+// assertIndividualAnonymity checks, that the identifier columns of the linked rows of each individual
+// are the same as the ones of at least k-1 other individuals.
+func assertIndividualAnonymity(tables []*model.Table, keys []int, k int, t *testing.T) {
+	t.Helper()
+	records := make(map[string][]string)
+	for ti, table := range tables {
+		for _, row := range table.GetRows() {
+			key := row.Data[keys[ti]].String()
+			if key == "*" {
+				continue
+			}
+			var values []string
+			for c, col := range table.GetSchema().Columns {
+				if col.IsIdentifier() {
+					values = append(values, row.Data[c].String())
+				}
+			}
+			records[key] = append(records[key], fmt.Sprintf("%d:%s", ti, strings.Join(values, ",")))
+		}
+	}
+	counts := make(map[string]int)
+	signatures := make(map[string]string)
+	for key, rows := range records {
+		sort.Strings(rows)
+		signatures[key] = strings.Join(rows, ";")
+		counts[signatures[key]]++
+	}
+	for key, signature := range signatures {
+		if counts[signature] < k {
+			t.Errorf("individual %s is indistinguishable from %d individuals, expected at least %d", key, counts[signature], k)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package kanon
+
+import (
+	"fmt"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+)
+
+// ExampleRepublication demonstrates the usage of m-invariant republication.
+// Like in ExampleContinuousAnonymization the data changes between the releases,
+// but rows can be deleted as well, so each version is supplied as a separate Table.
+// The Anonymizer keeps the history of the releases, and publishes each patient
+// with the same set of diseases in every release.
+func ExampleRepublication() {
+
+	schema := &model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("ID", nil),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 150)),
+			model.NewColumn("Zip", generalization.NewIntRangeGeneralizer(10000, 99999)),
+			model.NewColumn("Disease", nil),
+		},
+	}
+
+	anon := &Anonymizer{
+		M:         2,
+		ID:        "ID",
+		Sensitive: "Disease",
+	}
+
+	// first version
+	v1 := model.NewTable(schema)
+	v1.AddRow("Bob", 21, 12000, "dyspepsia")
+	v1.AddRow("Alice", 22, 14000, "bronchitis")
+	v1.AddRow("Andy", 24, 18000, "flu")
+	v1.AddRow("David", 23, 25000, "gastritis")
+	v1.AddRow("Gary", 41, 20000, "flu")
+	v1.AddRow("Helen", 36, 27000, "gastritis")
+
+	anon.Table = v1
+	release, err := anon.Publish()
+	if err != nil {
+		fmt.Printf("error: %v", err)
+		return
+	}
+
+	// second version: Alice and Gary are deleted, Emily and Jane are inserted
+	v2 := model.NewTable(schema)
+	v2.AddRow("Bob", 21, 12000, "dyspepsia")
+	v2.AddRow("Andy", 24, 18000, "flu")
+	v2.AddRow("David", 23, 25000, "gastritis")
+	v2.AddRow("Helen", 36, 27000, "gastritis")
+	v2.AddRow("Emily", 25, 21000, "flu")
+	v2.AddRow("Jane", 37, 33000, "pneumonia")
+
+	anon.Table = v2
+	release, err = anon.Publish()
+	if err != nil {
+		fmt.Printf("error: %v", err)
+		return
+	}
+
+	// print the second release, and the number of counterfeit rows in its groups
+	fmt.Printf("%v", release.Table)
+	fmt.Printf("counterfeits: %v", release.Counterfeits)
+
+	// the above will produce a similar Table:
+
+	//	ID	Age			Zip				Disease
+	//	*	[21]		[12000]			dyspepsia
+	//	*	[21]		[12000]			bronchitis
+	//	*	[22..26]	[10000..32499]	flu
+	//	*	[22..26]	[10000..32499]	gastritis
+	//	*	[18..36]	[10000..32499]	flu
+	//	*	[18..36]	[10000..32499]	gastritis
+	//	*	[37]		[33000]			pneumonia
+	//	*	[37]		[33000]			flu
+	//	counterfeits: [1 0 0 1]
+
+}
@@ -0,0 +1,262 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gar-r/k-anon/dp"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// SafePub is a differentially private variant of K-anonymization (Bild, Kuhn, Prasser:
+// "SafePub: A Truthful Data Anonymization Algorithm With Strong Privacy Guarantees").
+// It produces a table in the same format as the Anonymizer, in three steps:
+//   - each row of the Table is sampled with probability Beta
+//   - the generalization level of each identifier column is chosen with the exponential
+//     mechanism, searching the levels of the generalizers in Steps steps (defaults to 100)
+//   - the sampled rows are generalized to the chosen levels, and groups of less than K
+//     identical rows are suppressed
+//
+// The search spends Epsilon, and the sampling with the suppression provide (-ln(1-Beta), δ)
+// differential privacy, where δ depends on K and Beta. The sum of the two ε, and δ are
+// reported in the release. Unlike the Anonymizer, SafePub does not modify the Table.
+// Rand is the source of randomness (defaults to a time-seeded source).
+type SafePub struct {
+	K       int
+	Table   *model.Table
+	Beta    float64
+	Epsilon float64
+	Steps   int
+	Rand    *rand.Rand
+}
+
+// SafePubRelease is the result of SafePub: the anonymized table, the generalization
+// level of each column (zero for non-identifier columns), the number of sampled and
+// suppressed rows, and the differential privacy guarantee of the release.
+type SafePubRelease struct {
+	Table      *model.Table
+	Levels     []int
+	Sampled    int
+	Suppressed int
+	Epsilon    float64
+	Delta      float64
+}
+
+// Anonymize creates a differentially private K-anonymized release of the Table.
+func (s *SafePub) Anonymize() (*SafePubRelease, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	rnd := s.rand()
+	var sample []*model.Row
+	for _, row := range s.Table.GetRows() {
+		if rnd.Float64() < s.Beta {
+			sample = append(sample, row)
+		}
+	}
+	levels := s.search(sample, rnd)
+	rows, suppressed := s.generalize(sample, levels)
+	table := model.NewTable(s.Table.GetSchema())
+	table.AppendRows(rows...)
+	epsilon, delta := safePubPrivacy(s.K, s.Beta)
+	return &SafePubRelease{
+		Table:      table,
+		Levels:     levels,
+		Sampled:    len(sample),
+		Suppressed: suppressed,
+		Epsilon:    s.Epsilon + epsilon,
+		Delta:      delta,
+	}, nil
+}
+
+func (s *SafePub) validate() error {
+	if s.K < 1 {
+		return fmt.Errorf("invalid value for K: %d", s.K)
+	}
+	if s.Beta <= 0 || s.Beta >= 1 {
+		return fmt.Errorf("invalid sampling probability: %v", s.Beta)
+	}
+	if s.Epsilon <= 0 {
+		return fmt.Errorf("invalid ε: %v", s.Epsilon)
+	}
+	if s.Table == nil {
+		return errors.New("missing table")
+	}
+	return nil
+}
+
+// search walks the lattice of generalization levels from the bottom. In each step a node
+// is selected from the neighbours of the nodes selected so far with the exponential
+// mechanism, and finally the result is selected from all selected nodes the same way.
+// Each selection spends an equal share of Epsilon.
+func (s *SafePub) search(sample []*model.Row, rnd *rand.Rand) []int {
+	columns := s.Table.GetSchema().Columns
+	steps := s.Steps
+	if steps <= 0 {
+		steps = 100
+	}
+	epsilon := s.Epsilon / float64(steps+1)
+	sensitivity := float64(s.K)
+	scores := make(map[string]float64)
+	score := func(levels []int) float64 {
+		key := levelKey(levels)
+		if v, ok := scores[key]; ok {
+			return v
+		}
+		v := s.score(sample, levels)
+		scores[key] = v
+		return v
+	}
+
+	bottom := make([]int, len(columns))
+	candidates := [][]int{bottom}
+	seen := map[string]bool{levelKey(bottom): true}
+	var selected [][]int
+	for step := 0; step < steps && len(candidates) > 0; step++ {
+		candidateScores := make([]float64, len(candidates))
+		for i, c := range candidates {
+			candidateScores[i] = score(c)
+		}
+		i := dp.Exponential(candidateScores, epsilon, sensitivity, rnd)
+		node := candidates[i]
+		candidates = append(candidates[:i], candidates[i+1:]...)
+		selected = append(selected, node)
+		for _, n := range s.neighbours(node) {
+			if key := levelKey(n); !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, n)
+			}
+		}
+	}
+	selectedScores := make([]float64, len(selected))
+	for i, node := range selected {
+		selectedScores[i] = score(node)
+	}
+	return selected[dp.Exponential(selectedScores, epsilon, sensitivity, rnd)]
+}
+
+// neighbours returns the nodes one level above and below the given node in a single column,
+// within the generalization limit of the column.
+func (s *SafePub) neighbours(levels []int) [][]int {
+	var result [][]int
+	for colIdx, col := range s.Table.GetSchema().Columns {
+		if !col.IsIdentifier() {
+			continue
+		}
+		for _, d := range []int{-1, 1} {
+			l := levels[colIdx] + d
+			if l < 0 || l > col.GetMaxLevel() {
+				continue
+			}
+			n := append([]int(nil), levels...)
+			n[colIdx] = l
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// score returns the negative of the information loss of the sample generalized to the given
+// levels: 1 for each suppressed row, and the weighted average of the levels normalized by the
+// generalization limit of each column for the other rows. Adding or removing a row changes the loss of the row itself, and may suppress
+// or unsuppress K-1 other rows, so the sensitivity of the score is K.
+func (s *SafePub) score(sample []*model.Row, levels []int) float64 {
+	rows, suppressed := s.generalize(sample, levels)
+	var weights, loss float64
+	for colIdx, col := range s.Table.GetSchema().Columns {
+		if !col.IsIdentifier() {
+			continue
+		}
+		weights += col.GetWeight()
+		if top := col.GetMaxLevel(); top > 0 {
+			loss += col.GetWeight() * float64(levels[colIdx]) / float64(top)
+		}
+	}
+	if weights > 0 {
+		loss /= weights
+	}
+	return -(float64(suppressed) + float64(len(rows))*loss)
+}
+
+// generalize returns copies of the rows generalized to the given levels, leaving out the rows
+// in groups of less than K rows, and the number of rows left out. Values which cannot be
+// generalized, because they are not in the domain of the generalizer, are suppressed.
+func (s *SafePub) generalize(sample []*model.Row, levels []int) ([]*model.Row, int) {
+	groups := make(map[string][]*model.Row)
+	var keys []string
+	for _, row := range sample {
+		data := make([]partition.Partition, len(row.Data))
+		key := &strings.Builder{}
+		for colIdx, col := range s.Table.GetSchema().Columns {
+			data[colIdx] = row.Data[colIdx]
+			if col.IsIdentifier() {
+				data[colIdx] = col.GetGeneralizer().Generalize(row.Data[colIdx], levels[colIdx])
+				if data[colIdx] == nil { // the value cannot be generalized, so it is suppressed
+					data[colIdx] = partition.NewItem("*")
+				}
+				key.WriteString(data[colIdx].String())
+				key.WriteString("\t")
+			}
+		}
+		if _, ok := groups[key.String()]; !ok {
+			keys = append(keys, key.String())
+		}
+		groups[key.String()] = append(groups[key.String()], &model.Row{Data: data})
+	}
+	var rows []*model.Row
+	suppressed := 0
+	for _, key := range keys {
+		if len(groups[key]) < s.K {
+			suppressed += len(groups[key])
+		} else {
+			rows = append(rows, groups[key]...)
+		}
+	}
+	return rows, suppressed
+}
+
+func (s *SafePub) rand() *rand.Rand {
+	if s.Rand == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return s.Rand
+}
+
+func levelKey(levels []int) string {
+	return fmt.Sprint(levels)
+}
+
+// safePubPrivacy returns the differential privacy guarantee of sampling rows with probability
+// beta, and suppressing groups of less than k rows (Li, Qardaji, Su: "On Sampling,
+// Anonymization, and Differential Privacy"): ε = -ln(1-β), and δ is the largest probability
+// of sampling more than γn rows out of n ≥ ceil(k/γ - 1), where γ = β(2-β).
+func safePubPrivacy(k int, beta float64) (float64, float64) {
+	epsilon := -math.Log(1 - beta)
+	gamma := beta * (2 - beta)
+	start := max(1, int(math.Ceil(float64(k)/gamma-1)))
+	// the tail is bounded by exp(-n KL(γ||β)), so the search stops, when the bound
+	// drops below the largest tail found so far
+	kl := gamma*math.Log(gamma/beta) + (1-gamma)*math.Log((1-gamma)/(1-beta))
+	var delta float64
+	for n := start; math.Exp(-float64(n)*kl) > delta; n++ {
+		delta = math.Max(delta, binomialTail(n, int(math.Floor(gamma*float64(n)))+1, beta))
+	}
+	return epsilon, delta
+}
+
+// binomialTail returns the probability of at least j successes out of n with probability p.
+func binomialTail(n, j int, p float64) float64 {
+	var tail float64
+	lgN, _ := math.Lgamma(float64(n + 1))
+	for i := j; i <= n; i++ {
+		lgI, _ := math.Lgamma(float64(i + 1))
+		lgNI, _ := math.Lgamma(float64(n - i + 1))
+		tail += math.Exp(lgN - lgI - lgNI + float64(i)*math.Log(p) + float64(n-i)*math.Log(1-p))
+	}
+	return tail
+}
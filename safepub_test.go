@@ -0,0 +1,149 @@
+package kanon
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getSafePubTable(n int) *model.Table {
+	rnd := rand.New(rand.NewSource(1))
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Name", nil),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 127)),
+			model.NewColumn("Grade", generalization.ExampleGradeGeneralizer()),
+		},
+	})
+	grades := []string{"A+", "A", "A-", "B+", "B", "B-", "C+", "C", "C-"}
+	for i := 0; i < n; i++ {
+		table.AddRow(testutil.RandString(5), rnd.Intn(100), grades[rnd.Intn(len(grades))])
+	}
+	return table
+}
+
+func TestSafePub_Anonymize(t *testing.T) {
+
+	t.Run("release is k-anonymous", func(t *testing.T) {
+		table := getSafePubTable(500)
+		s := &SafePub{
+			K:       5,
+			Table:   table,
+			Beta:    0.5,
+			Epsilon: 1,
+			Rand:    rand.New(rand.NewSource(2)),
+		}
+		release, err := s.Anonymize()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(3, len(release.Levels), t)
+		testutil.AssertEquals(0, release.Levels[0], t)
+		testutil.AssertEquals(release.Sampled, len(release.Table.GetRows())+release.Suppressed, t)
+		if release.Sampled < 200 || release.Sampled > 300 {
+			t.Errorf("expected about 250 sampled rows, got %d", release.Sampled)
+		}
+		assertKAnonymity(release.Table, 5, t)
+		testutil.AssertEquals(true, math.Abs(release.Epsilon-(1+math.Log(2))) < 1e-12, t)
+		if release.Delta <= 0 || release.Delta >= 1 {
+			t.Errorf("unexpected δ: %v", release.Delta)
+		}
+		testutil.AssertEquals(500, len(table.GetRows()), t)
+	})
+
+	t.Run("search finds low information loss", func(t *testing.T) {
+		table := getSafePubTable(500)
+		s := &SafePub{
+			K:       5,
+			Table:   table,
+			Beta:    0.5,
+			Epsilon: 1e6,
+			Steps:   50,
+			Rand:    rand.New(rand.NewSource(3)),
+		}
+		release, err := s.Anonymize()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		bottom := s.score(table.GetRows(), []int{0, 0, 0})
+		top := s.score(table.GetRows(), []int{0, 7, 2})
+		chosen := s.score(table.GetRows(), release.Levels)
+		if chosen < bottom || chosen < top {
+			t.Errorf("expected better score than the bottom and top nodes: %v, %v, %v", chosen, bottom, top)
+		}
+	})
+
+	t.Run("levels stay within the generalization limit", func(t *testing.T) {
+		table := getSafePubTable(500)
+		table.GetSchema().Columns[1].WithMaxLevel(2)
+		for seed := int64(0); seed < 10; seed++ {
+			s := &SafePub{
+				K:       5,
+				Table:   table,
+				Beta:    0.5,
+				Epsilon: 1,
+				Steps:   50,
+				Rand:    rand.New(rand.NewSource(seed)),
+			}
+			release, err := s.Anonymize()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if release.Levels[1] > 2 {
+				t.Errorf("expected level at most 2, got %d", release.Levels[1])
+			}
+		}
+	})
+
+	t.Run("same seed gives same release", func(t *testing.T) {
+		table := getSafePubTable(100)
+		r1, _ := (&SafePub{K: 2, Table: table, Beta: 0.5, Epsilon: 1, Rand: rand.New(rand.NewSource(4))}).Anonymize()
+		r2, _ := (&SafePub{K: 2, Table: table, Beta: 0.5, Epsilon: 1, Rand: rand.New(rand.NewSource(4))}).Anonymize()
+		testutil.AssertEquals(r1.Table.String(), r2.Table.String(), t)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		table := getSafePubTable(10)
+		tests := []*SafePub{
+			{K: 0, Table: table, Beta: 0.5, Epsilon: 1},
+			{K: 2, Table: table, Beta: 0, Epsilon: 1},
+			{K: 2, Table: table, Beta: 1, Epsilon: 1},
+			{K: 2, Table: table, Beta: 0.5, Epsilon: 0},
+			{K: 2, Beta: 0.5, Epsilon: 1},
+		}
+		for _, s := range tests {
+			if _, err := s.Anonymize(); err == nil {
+				t.Errorf("expected error for %+v", s)
+			}
+		}
+	})
+}
+
+func TestSafePub_Generalize(t *testing.T) {
+	table := getSafePubTable(0)
+	table.AddRow("Joe", 200, "A")
+	s := &SafePub{K: 1, Table: table}
+	rows, suppressed := s.generalize(table.GetRows(), []int{0, 0, 0})
+	testutil.AssertEquals(0, suppressed, t)
+	testutil.AssertEquals("*", rows[0].Data[1].String(), t)
+}
+
+func TestSafePubPrivacy(t *testing.T) {
+	epsilon, delta := safePubPrivacy(2, 0.5)
+	testutil.AssertEquals(true, math.Abs(epsilon-math.Log(2)) < 1e-12, t)
+	testutil.AssertEquals(true, math.Abs(delta-0.25) < 1e-12, t)
+	_, delta100 := safePubPrivacy(100, 0.5)
+	if delta100 >= delta || delta100 <= 0 {
+		t.Errorf("expected δ to decrease with K, got %v", delta100)
+	}
+}
+
+func TestBinomialTail(t *testing.T) {
+	testutil.AssertEquals(true, math.Abs(binomialTail(3, 2, 0.5)-0.5) < 1e-12, t)
+	testutil.AssertEquals(true, math.Abs(binomialTail(4, 0, 0.3)-1) < 1e-12, t)
+	testutil.AssertEquals(0.0, binomialTail(4, 5, 0.3), t)
+}
@@ -0,0 +1,242 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// KMAnonymizer anonymizes a set-valued column of the Table (see model.NewSetValuedColumn), so
+// that an attacker, who knows up to M items of a row, always matches at least K rows, or none
+// (k^m-anonymity). It uses the Apriori anonymization of Terrovitis, Mamoulis and Kalnis:
+// "Privacy-preserving Anonymization of Set-valued Data". The items are generalized globally
+// with the item hierarchy of the column, so each occurrence of an item is replaced with the
+// same hierarchy node. A generalized item is represented by the string of its hierarchy node,
+// for example "[A, A+, A-]", and the items of a row generalized into the same node appear once.
+type KMAnonymizer struct {
+	K      int
+	M      int
+	Table  *model.Table
+	Column string
+}
+
+// cut maps each leaf of the item hierarchy to the node, which it is generalized into.
+// A node and its descendants are never both in the cut.
+type cut struct {
+	nodes   map[interface{}]hierarchy.Hierarchy // current node of each leaf item
+	leaves  map[hierarchy.Hierarchy][]interface{}
+	parents map[hierarchy.Hierarchy]hierarchy.Hierarchy
+}
+
+// Anonymize generalizes the items of the column in place. The itemsets of size 1 to M are
+// checked in order, and while an itemset is supported by less than K rows, one of its items
+// is generalized to its parent, picking the parent with the least leaves.
+func (a *KMAnonymizer) Anonymize() error {
+	colIdx, err := a.validate()
+	if err != nil {
+		return err
+	}
+	col := a.Table.GetSchema().Columns[colIdx]
+	c := newCut(col.GetItemHierarchy())
+	transactions := make([][]interface{}, len(a.Table.GetRows()))
+	for i, row := range a.Table.GetRows() {
+		set, ok := row.Data[colIdx].(*partition.Set)
+		if !ok {
+			return fmt.Errorf("row %d: column %s must contain a set of items, got %v", i, a.Column, row.Data[colIdx])
+		}
+		for item := range set.Items {
+			if _, ok := c.nodes[item]; !ok {
+				return fmt.Errorf("row %d: item %v is not in the item hierarchy", i, item)
+			}
+			transactions[i] = append(transactions[i], item)
+		}
+	}
+	for size := 1; size <= a.M; size++ {
+		for {
+			itemset := a.violation(c, transactions, size)
+			if itemset == nil {
+				break
+			}
+			if !c.generalize(itemset) {
+				return fmt.Errorf("cannot achieve k^m-anonymity: items %s are fully generalized, but supported by less than %d rows",
+					strings.ReplaceAll(itemsetKey(itemset), "\x00", ", "), a.K)
+			}
+		}
+	}
+	for i, row := range a.Table.GetRows() {
+		row.Data[colIdx] = partition.NewSet(c.labels(transactions[i])...)
+	}
+	return nil
+}
+
+func (a *KMAnonymizer) validate() (int, error) {
+	if a.K < 1 || a.M < 1 {
+		return 0, fmt.Errorf("invalid values for K and M: %d, %d", a.K, a.M)
+	}
+	if a.Table == nil {
+		return 0, errors.New("missing table")
+	}
+	colIdx := a.Table.GetSchema().IndexOf(a.Column)
+	if colIdx < 0 || !a.Table.GetSchema().Columns[colIdx].IsSetValued() {
+		return 0, fmt.Errorf("%s is not a set-valued column", a.Column)
+	}
+	return colIdx, nil
+}
+
+// violation returns an itemset of the given size of generalized items, which is supported
+// by at least one, but less than K rows, or nil if there is no such itemset. The itemset with
+// the lowest support is returned, ties are broken by the labels of the items.
+func (a *KMAnonymizer) violation(c *cut, transactions [][]interface{}, size int) []hierarchy.Hierarchy {
+	support := make(map[string]int)
+	itemsets := make(map[string][]hierarchy.Hierarchy)
+	for _, t := range transactions {
+		nodes := c.generalizedNodes(t)
+		combinations(nodes, size, func(itemset []hierarchy.Hierarchy) {
+			key := itemsetKey(itemset)
+			if _, ok := itemsets[key]; !ok {
+				itemsets[key] = append([]hierarchy.Hierarchy(nil), itemset...)
+			}
+			support[key]++
+		})
+	}
+	var result string
+	for key, n := range support {
+		if n >= a.K {
+			continue
+		}
+		if result == "" || n < support[result] || (n == support[result] && key < result) {
+			result = key
+		}
+	}
+	if result == "" {
+		return nil
+	}
+	return itemsets[result]
+}
+
+func newCut(h hierarchy.Hierarchy) *cut {
+	c := &cut{
+		nodes:   make(map[interface{}]hierarchy.Hierarchy),
+		leaves:  make(map[hierarchy.Hierarchy][]interface{}),
+		parents: make(map[hierarchy.Hierarchy]hierarchy.Hierarchy),
+	}
+	var walk func(n hierarchy.Hierarchy) []interface{}
+	walk = func(n hierarchy.Hierarchy) []interface{} {
+		var leaves []interface{}
+		if len(n.Children()) == 0 {
+			switch p := n.Partition().(type) {
+			case *partition.Set:
+				for item := range p.Items {
+					leaves = append(leaves, item)
+				}
+			case *partition.Item:
+				leaves = append(leaves, p.GetItem())
+			}
+			for _, item := range leaves {
+				c.nodes[item] = n
+			}
+		}
+		for _, child := range n.Children() {
+			c.parents[child] = n
+			leaves = append(leaves, walk(child)...)
+		}
+		c.leaves[n] = leaves
+		return leaves
+	}
+	walk(h)
+	return c
+}
+
+// generalize replaces the node of an item of the itemset in the cut with its parent, picking
+// the parent with the least leaves. Returns false if all items are at the root.
+func (c *cut) generalize(itemset []hierarchy.Hierarchy) bool {
+	var best hierarchy.Hierarchy
+	for _, n := range itemset {
+		p, ok := c.parents[n]
+		if ok && (best == nil || len(c.leaves[p]) < len(c.leaves[best])) {
+			best = p
+		}
+	}
+	if best == nil {
+		return false
+	}
+	for _, item := range c.leaves[best] {
+		c.nodes[item] = best
+	}
+	return true
+}
+
+// generalizedNodes returns the distinct nodes of the items of a transaction, ordered by their labels.
+func (c *cut) generalizedNodes(items []interface{}) []hierarchy.Hierarchy {
+	seen := make(map[hierarchy.Hierarchy]bool)
+	var nodes []hierarchy.Hierarchy
+	for _, item := range items {
+		n := c.nodes[item]
+		if !seen[n] {
+			seen[n] = true
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return label(nodes[i]) < label(nodes[j])
+	})
+	return nodes
+}
+
+// labels returns the generalized items of a transaction.
+func (c *cut) labels(items []interface{}) []interface{} {
+	var result []interface{}
+	for _, n := range c.generalizedNodes(items) {
+		if len(n.Children()) == 0 && len(c.leaves[n]) == 1 {
+			result = append(result, c.leaves[n][0])
+		} else {
+			result = append(result, label(n))
+		}
+	}
+	return result
+}
+
+func label(n hierarchy.Hierarchy) string {
+	if len(n.Children()) == 0 {
+		if p, ok := n.Partition().(*partition.Set); ok && len(p.Items) == 1 {
+			for item := range p.Items {
+				return fmt.Sprint(item)
+			}
+		}
+	}
+	return n.Partition().String()
+}
+
+func itemsetKey(itemset []hierarchy.Hierarchy) string {
+	labels := make([]string, len(itemset))
+	for i, n := range itemset {
+		labels[i] = label(n)
+	}
+	return strings.Join(labels, "\x00")
+}
+
+// combinations calls f with each subset of the given size of the nodes, keeping their order.
+func combinations(nodes []hierarchy.Hierarchy, size int, f func([]hierarchy.Hierarchy)) {
+	if size > len(nodes) {
+		return
+	}
+	current := make([]hierarchy.Hierarchy, 0, size)
+	var rec func(start int)
+	rec = func(start int) {
+		if len(current) == size {
+			f(current)
+			return
+		}
+		for i := start; i <= len(nodes)-(size-len(current)); i++ {
+			current = append(current, nodes[i])
+			rec(i + 1)
+			current = current[:len(current)-1]
+		}
+	}
+	rec(0)
+}
@@ -0,0 +1,138 @@
+package kanon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getProductHierarchy() hierarchy.Hierarchy {
+	h, _ := hierarchy.Build(partition.NewSet("bread", "milk", "cheese", "beer", "wine", "water"),
+		hierarchy.N(partition.NewSet("bread", "milk", "cheese"),
+			hierarchy.N(partition.NewSet("bread")),
+			hierarchy.N(partition.NewSet("milk")),
+			hierarchy.N(partition.NewSet("cheese"))),
+		hierarchy.N(partition.NewSet("beer", "wine", "water"),
+			hierarchy.N(partition.NewSet("beer")),
+			hierarchy.N(partition.NewSet("wine")),
+			hierarchy.N(partition.NewSet("water"))))
+	return h
+}
+
+func getPurchaseTable() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Name", nil),
+			model.NewSetValuedColumn("Purchased", getProductHierarchy()),
+		},
+	})
+	table.AddRow("Alice", []string{"bread", "milk"})
+	table.AddRow("Bob", []string{"bread", "milk"})
+	table.AddRow("Carol", []string{"bread", "beer"})
+	table.AddRow("Dave", []string{"cheese", "wine"})
+	table.AddRow("Eve", []string{"milk", "water", "cheese"})
+	table.AddRow("Frank", []string{"bread", "wine", "milk"})
+	table.AddRow("Grace", []string{})
+	return table
+}
+
+func TestKMAnonymizer_Anonymize(t *testing.T) {
+
+	t.Run("k^m-anonymity", func(t *testing.T) {
+		for _, m := range []int{1, 2, 3} {
+			table := getPurchaseTable()
+			anon := &KMAnonymizer{K: 2, M: m, Table: table, Column: "Purchased"}
+			if err := anon.Anonymize(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertKMAnonymity(table, 1, 2, m, t)
+		}
+	})
+
+	t.Run("frequent items are kept", func(t *testing.T) {
+		table := getPurchaseTable()
+		anon := &KMAnonymizer{K: 2, M: 1, Table: table, Column: "Purchased"}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		first := table.GetRows()[0].Data[1].(*partition.Set)
+		testutil.AssertEquals(true, first.Contains("bread"), t)
+		testutil.AssertEquals(true, first.Contains("milk"), t)
+		drinks := table.GetRows()[2].Data[1].(*partition.Set)
+		testutil.AssertEquals(true, drinks.Contains("[beer, water, wine]"), t)
+		testutil.AssertEquals(0, len(table.GetRows()[6].Data[1].(*partition.Set).Items), t)
+	})
+
+	t.Run("nothing to generalize", func(t *testing.T) {
+		table := getPurchaseTable()
+		before := table.String()
+		anon := &KMAnonymizer{K: 1, M: 2, Table: table, Column: "Purchased"}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(before, table.String(), t)
+	})
+
+	t.Run("too few rows", func(t *testing.T) {
+		anon := &KMAnonymizer{K: 7, M: 1, Table: getPurchaseTable(), Column: "Purchased"}
+		testutil.AssertNotNil(anon.Anonymize(), t)
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		table := getPurchaseTable()
+		table.AddRow("Heidi", []string{"bread", "cake"})
+		anon := &KMAnonymizer{K: 2, M: 1, Table: table, Column: "Purchased"}
+		err := anon.Anonymize()
+		if err == nil || !strings.Contains(err.Error(), "cake") {
+			t.Errorf("expected error for item cake, got %v", err)
+		}
+	})
+
+	t.Run("not a set-valued column", func(t *testing.T) {
+		anon := &KMAnonymizer{K: 2, M: 1, Table: getPurchaseTable(), Column: "Name"}
+		testutil.AssertNotNil(anon.Anonymize(), t)
+	})
+}
+
+// assertKMAnonymity checks that each itemset of at most m items in the given column is
+// supported by zero or at least k rows.
+func assertKMAnonymity(table *model.Table, colIdx, k, m int, t *testing.T) {
+	t.Helper()
+	support := make(map[string]int)
+	for _, row := range table.GetRows() {
+		var items []string
+		for _, item := range strings.Split(strings.Trim(row.Data[colIdx].String(), "[]"), ", ") {
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		subsets(items, m, func(itemset []string) {
+			support[strings.Join(itemset, "|")]++
+		})
+	}
+	for itemset, n := range support {
+		if n < k {
+			t.Errorf("itemset %s is supported by %d rows, expected at least %d", itemset, n, k)
+		}
+	}
+}
+
+func subsets(items []string, m int, f func([]string)) {
+	var rec func(start int, current []string)
+	rec = func(start int, current []string) {
+		if len(current) > 0 {
+			f(current)
+		}
+		if len(current) == m {
+			return
+		}
+		for i := start; i < len(items); i++ {
+			rec(i+1, append(current, items[i]))
+		}
+	}
+	rec(0, nil)
+}
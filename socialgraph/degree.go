@@ -0,0 +1,209 @@
+package socialgraph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Anonymize returns a k-degree anonymous supergraph of g, where each node shares its degree with at least
+// k-1 other nodes. It follows Liu and Terzi: "Towards Identity Anonymization on Graphs". The degree sequence
+// is anonymized first with dynamic programming, raising the degrees of each group of nodes to the largest
+// degree in the group, then edges are added between the nodes, which need more edges to reach their degree.
+// When the degree sequence cannot be realized this way, the missing edges are connected to the nodes of the
+// lowest degree, and the degree sequence is anonymized again. The input graph is not modified, and the nodes
+// of the result have the same IDs as the nodes of g. The degrees are counted in simple graphs, so graphs
+// with self-loops or parallel edges are rejected with an error.
+func Anonymize(g graph.Undirected, k int) (*simple.UndirectedGraph, error) {
+	nodes := sortedNodes(g)
+	if k < 1 {
+		return nil, fmt.Errorf("invalid value for k: %d", k)
+	}
+	if len(nodes) < k {
+		return nil, fmt.Errorf("cannot anonymize %d nodes with k=%d", len(nodes), k)
+	}
+	if err := checkSimple(g, nodes); err != nil {
+		return nil, err
+	}
+	result := simple.NewUndirectedGraph()
+	graph.Copy(result, g)
+	for {
+		degrees := nodeDegrees(result, nodes)
+		targets, err := AnonymizeDegrees(degrees, k)
+		if err != nil {
+			return nil, err
+		}
+		residuals := make([]int, len(nodes))
+		done := true
+		for i := range nodes {
+			residuals[i] = targets[i] - degrees[i]
+			done = done && residuals[i] == 0
+		}
+		if done {
+			return result, nil
+		}
+		if !addEdges(result, nodes, residuals) {
+			probe(result, nodes, residuals)
+		}
+	}
+}
+
+// IsKDegreeAnonymous returns true, when each degree in g is shared by at least k nodes.
+func IsKDegreeAnonymous(g graph.Undirected, k int) bool {
+	counts := make(map[int]int)
+	for _, degree := range nodeDegrees(g, sortedNodes(g)) {
+		counts[degree]++
+	}
+	for _, count := range counts {
+		if count < k {
+			return false
+		}
+	}
+	return true
+}
+
+// AnonymizeDegrees returns the k-anonymous degree sequence closest to the given degrees, where each degree
+// occurs at least k times, and no degree is decreased. The total increase of the degrees is minimal.
+// The degrees are returned in the order of the input.
+func AnonymizeDegrees(degrees []int, k int) ([]int, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid value for k: %d", k)
+	}
+	n := len(degrees)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return degrees[order[i]] > degrees[order[j]]
+	})
+	sums := make([]int, n+1)
+	for i, idx := range order {
+		sums[i+1] = sums[i] + degrees[idx]
+	}
+	// cost of raising the degrees of the sorted nodes [i, j) to the degree of node i
+	cost := func(i, j int) int {
+		return degrees[order[i]]*(j-i) - (sums[j] - sums[i])
+	}
+	best := make([]int, n+1)
+	start := make([]int, n+1)
+	for j := 1; j <= n; j++ {
+		best[j] = math.MaxInt
+		for size := k; size <= 2*k-1 && size <= j; size++ {
+			i := j - size
+			if i > 0 && i < k || best[i] == math.MaxInt {
+				continue
+			}
+			if c := best[i] + cost(i, j); c < best[j] {
+				best[j], start[j] = c, i
+			}
+		}
+		if best[j] == math.MaxInt && j < k {
+			best[j], start[j] = cost(0, j), 0
+		}
+	}
+	result := make([]int, n)
+	for j := n; j > 0; j = start[j] {
+		for i := start[j]; i < j; i++ {
+			result[order[i]] = degrees[order[start[j]]]
+		}
+	}
+	return result, nil
+}
+
+// addEdges connects the nodes with positive residual degrees, always starting with the node with the
+// largest residual, and connecting it to the non-adjacent nodes with the largest residuals.
+// It returns false, when a node cannot be connected to enough nodes.
+func addEdges(g *simple.UndirectedGraph, nodes []graph.Node, residuals []int) bool {
+	for {
+		v := -1
+		for i, r := range residuals {
+			if r > 0 && (v < 0 || r > residuals[v]) {
+				v = i
+			}
+		}
+		if v < 0 {
+			return true
+		}
+		var candidates []int
+		for i, r := range residuals {
+			if i != v && r > 0 && !g.HasEdgeBetween(nodes[v].ID(), nodes[i].ID()) {
+				candidates = append(candidates, i)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return residuals[candidates[i]] > residuals[candidates[j]]
+		})
+		if len(candidates) > residuals[v] {
+			candidates = candidates[:residuals[v]]
+		}
+		for _, u := range candidates {
+			g.SetEdge(g.NewEdge(nodes[v], nodes[u]))
+			residuals[u]--
+			residuals[v]--
+		}
+		if residuals[v] > 0 {
+			return false
+		}
+	}
+}
+
+// probe connects each node with a positive residual degree to the non-adjacent nodes of the lowest degree,
+// until its residual is satisfied. The degree and the residual of each node add up to its target degree, which is
+// at most the number of nodes minus one, so a node with a positive residual always has a non-adjacent node.
+func probe(g *simple.UndirectedGraph, nodes []graph.Node, residuals []int) {
+	for v := range residuals {
+		for residuals[v] > 0 {
+			u := -1
+			for i, node := range nodes {
+				if i == v || g.HasEdgeBetween(nodes[v].ID(), node.ID()) {
+					continue
+				}
+				if u < 0 || g.From(node.ID()).Len() < g.From(nodes[u].ID()).Len() {
+					u = i
+				}
+			}
+			g.SetEdge(g.NewEdge(nodes[v], nodes[u]))
+			residuals[u]--
+			residuals[v]--
+		}
+	}
+}
+
+// checkSimple returns an error, when g has a self-loop, or parallel edges between two nodes.
+func checkSimple(g graph.Undirected, nodes []graph.Node) error {
+	for _, v := range nodes {
+		neighbours := g.From(v.ID())
+		for neighbours.Next() {
+			u := neighbours.Node()
+			if u.ID() == v.ID() {
+				return fmt.Errorf("graph has a self-loop on node %d", v.ID())
+			}
+			if lines, ok := g.Edge(v.ID(), u.ID()).(graph.Lines); ok && lines.Len() > 1 {
+				return fmt.Errorf("graph has %d parallel edges between nodes %d and %d", lines.Len(), v.ID(), u.ID())
+			}
+		}
+	}
+	return nil
+}
+
+func nodeDegrees(g graph.Undirected, nodes []graph.Node) []int {
+	degrees := make([]int, len(nodes))
+	for i, n := range nodes {
+		degrees[i] = g.From(n.ID()).Len()
+	}
+	return degrees
+}
+
+// sortedNodes returns the nodes of the graph sorted by ID, so the results do not depend on the
+// random iteration order of gonum graphs.
+func sortedNodes(g graph.Graph) []graph.Node {
+	nodes := graph.NodesOf(g.Nodes())
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID() < nodes[j].ID()
+	})
+	return nodes
+}
@@ -0,0 +1,137 @@
+package socialgraph
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/testutil"
+	"gonum.org/v1/gonum/graph/multi"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func getRandomGraph(n int, p float64, rnd *rand.Rand) *simple.UndirectedGraph {
+	g := algorithm.CreateNodesUndirected(n)
+	for u := 0; u < n; u++ {
+		for v := u + 1; v < n; v++ {
+			if rnd.Float64() < p {
+				algorithm.AddEdge(g, int64(u), int64(v))
+			}
+		}
+	}
+	return g
+}
+
+func TestAnonymizeDegrees(t *testing.T) {
+	tests := []struct {
+		degrees  []int
+		k        int
+		expected []int
+	}{
+		{[]int{1, 2, 3}, 1, []int{1, 2, 3}},
+		{[]int{5, 5, 4, 3, 3, 1}, 2, []int{5, 5, 4, 4, 3, 3}},
+		{[]int{1, 5, 3, 3, 4, 5}, 2, []int{3, 5, 4, 3, 4, 5}},
+		{[]int{4, 3, 3, 3, 1}, 2, []int{4, 4, 3, 3, 3}},
+		{[]int{2, 1}, 3, []int{2, 2}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v, k=%d", test.degrees, test.k), func(t *testing.T) {
+			actual, err := AnonymizeDegrees(test.degrees, test.k)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.AssertEquals(fmt.Sprint(test.expected), fmt.Sprint(actual), t)
+		})
+	}
+
+	t.Run("invalid k", func(t *testing.T) {
+		for _, k := range []int{0, -1} {
+			if _, err := AnonymizeDegrees([]int{1, 1}, k); err == nil {
+				t.Errorf("expected error for k=%d", k)
+			}
+		}
+	})
+}
+
+func TestAnonymize(t *testing.T) {
+
+	t.Run("k-degree anonymity", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		for _, k := range []int{1, 2, 3, 5, 10} {
+			for i := 0; i < 5; i++ {
+				g := getRandomGraph(40, 0.1, rnd)
+				anonymized, err := Anonymize(g, k)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !IsKDegreeAnonymous(anonymized, k) {
+					t.Errorf("graph is not %d-degree anonymous", k)
+				}
+				assertSupergraph(g, anonymized, t)
+			}
+		}
+	})
+
+	t.Run("star graph", func(t *testing.T) {
+		g := algorithm.CreateNodesUndirected(5)
+		for v := int64(1); v < 5; v++ {
+			algorithm.AddEdge(g, 0, v)
+		}
+		anonymized, err := Anonymize(g, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(true, IsKDegreeAnonymous(anonymized, 2), t)
+		testutil.AssertEquals(false, IsKDegreeAnonymous(g, 2), t)
+		assertSupergraph(g, anonymized, t)
+	})
+
+	t.Run("input is not modified", func(t *testing.T) {
+		g := getRandomGraph(20, 0.2, rand.New(rand.NewSource(2)))
+		edges := edgeCount(g)
+		if _, err := Anonymize(g, 4); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(edges, edgeCount(g), t)
+	})
+
+	t.Run("invalid k", func(t *testing.T) {
+		g := algorithm.CreateNodesUndirected(3)
+		for _, k := range []int{0, 4} {
+			if _, err := Anonymize(g, k); err == nil {
+				t.Errorf("expected error for k=%d", k)
+			}
+		}
+	})
+
+	t.Run("self-loop", func(t *testing.T) {
+		g := multi.NewUndirectedGraph()
+		g.SetLine(g.NewLine(multi.Node(0), multi.Node(1)))
+		g.SetLine(g.NewLine(multi.Node(1), multi.Node(1)))
+		if _, err := Anonymize(g, 2); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("parallel edges", func(t *testing.T) {
+		g := multi.NewUndirectedGraph()
+		g.SetLine(g.NewLine(multi.Node(0), multi.Node(1)))
+		g.SetLine(g.NewLine(multi.Node(0), multi.Node(1)))
+		if _, err := Anonymize(g, 2); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func assertSupergraph(g, supergraph *simple.UndirectedGraph, t *testing.T) {
+	t.Helper()
+	testutil.AssertEquals(g.Nodes().Len(), supergraph.Nodes().Len(), t)
+	edges := g.Edges()
+	for edges.Next() {
+		e := edges.Edge()
+		if !supergraph.HasEdgeBetween(e.From().ID(), e.To().ID()) {
+			t.Errorf("missing edge %d-%d", e.From().ID(), e.To().ID())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package socialgraph
+
+import (
+	"gonum.org/v1/gonum/graph"
+)
+
+// Report describes the utility loss caused by anonymizing a graph.
+// The clustering coefficients are the average local clustering coefficients of the nodes, where nodes
+// with less than two neighbors have a coefficient of 0. The path lengths are the average shortest
+// path lengths between the pairs of connected nodes.
+type Report struct {
+	AddedEdges           int
+	OriginalClustering   float64
+	AnonymizedClustering float64
+	ClusteringChange     float64
+	OriginalPathLength   float64
+	AnonymizedPathLength float64
+	PathLengthChange     float64
+}
+
+// Evaluate compares the original graph with its anonymized version.
+func Evaluate(original, anonymized graph.Undirected) *Report {
+	r := &Report{
+		AddedEdges:           edgeCount(anonymized) - edgeCount(original),
+		OriginalClustering:   ClusteringCoefficient(original),
+		AnonymizedClustering: ClusteringCoefficient(anonymized),
+		OriginalPathLength:   AveragePathLength(original),
+		AnonymizedPathLength: AveragePathLength(anonymized),
+	}
+	r.ClusteringChange = r.AnonymizedClustering - r.OriginalClustering
+	r.PathLengthChange = r.AnonymizedPathLength - r.OriginalPathLength
+	return r
+}
+
+// ClusteringCoefficient returns the average local clustering coefficient of the nodes of g.
+func ClusteringCoefficient(g graph.Undirected) float64 {
+	nodes := sortedNodes(g)
+	if len(nodes) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, n := range nodes {
+		neighbors := graph.NodesOf(g.From(n.ID()))
+		if len(neighbors) < 2 {
+			continue
+		}
+		links := 0
+		for i, u := range neighbors {
+			for _, v := range neighbors[i+1:] {
+				if g.HasEdgeBetween(u.ID(), v.ID()) {
+					links++
+				}
+			}
+		}
+		total += 2 * float64(links) / float64(len(neighbors)*(len(neighbors)-1))
+	}
+	return total / float64(len(nodes))
+}
+
+// AveragePathLength returns the average shortest path length between the pairs of connected nodes of g,
+// or 0 if there are no connected nodes.
+func AveragePathLength(g graph.Undirected) float64 {
+	total, pairs := 0, 0
+	for _, n := range sortedNodes(g) {
+		distances := map[int64]int{n.ID(): 0}
+		queue := []int64{n.ID()}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			neighbors := g.From(u)
+			for neighbors.Next() {
+				v := neighbors.Node().ID()
+				if _, ok := distances[v]; !ok {
+					distances[v] = distances[u] + 1
+					total += distances[v]
+					pairs++
+					queue = append(queue, v)
+				}
+			}
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return float64(total) / float64(pairs)
+}
+
+func edgeCount(g graph.Undirected) int {
+	count := 0
+	for _, n := range sortedNodes(g) {
+		count += g.From(n.ID()).Len()
+	}
+	return count / 2
+}
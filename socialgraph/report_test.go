@@ -0,0 +1,50 @@
+package socialgraph
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/algorithm"
+	"github.com/gar-r/k-anon/testutil"
+	"gonum.org/v1/gonum/floats/scalar"
+)
+
+func TestClusteringCoefficient(t *testing.T) {
+	g := algorithm.CreateNodesUndirected(4)
+	algorithm.AddEdge(g, 0, 1)
+	algorithm.AddEdge(g, 1, 2)
+	algorithm.AddEdge(g, 2, 0)
+	algorithm.AddEdge(g, 2, 3)
+	// node 2 has 1 link out of 3 possible among its neighbors, node 3 has a single neighbor
+	expected := (1 + 1 + 1.0/3 + 0) / 4
+	testutil.AssertEquals(true, scalar.EqualWithinAbs(expected, ClusteringCoefficient(g), 1e-9), t)
+	testutil.AssertEquals(0.0, ClusteringCoefficient(algorithm.CreateNodesUndirected(0)), t)
+}
+
+func TestAveragePathLength(t *testing.T) {
+	g := algorithm.CreateNodesUndirected(5)
+	algorithm.AddEdge(g, 0, 1)
+	algorithm.AddEdge(g, 1, 2)
+	algorithm.AddEdge(g, 3, 4)
+	// ordered pairs: 0-1, 1-2, 3-4 at distance 1, and 0-2 at distance 2, in both directions
+	testutil.AssertEquals(5.0/4, AveragePathLength(g), t)
+	testutil.AssertEquals(0.0, AveragePathLength(algorithm.CreateNodesUndirected(3)), t)
+}
+
+func TestEvaluate(t *testing.T) {
+	g := algorithm.CreateNodesUndirected(5)
+	for v := int64(1); v < 5; v++ {
+		algorithm.AddEdge(g, 0, v)
+	}
+	anonymized, err := Anonymize(g, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := Evaluate(g, anonymized)
+	testutil.AssertEquals(edgeCount(anonymized)-4, r.AddedEdges, t)
+	testutil.AssertEquals(true, r.AddedEdges > 0, t)
+	testutil.AssertEquals(0.0, r.OriginalClustering, t)
+	testutil.AssertEquals(r.AnonymizedClustering-r.OriginalClustering, r.ClusteringChange, t)
+	testutil.AssertEquals(1.6, r.OriginalPathLength, t)
+	testutil.AssertEquals(r.AnonymizedPathLength-r.OriginalPathLength, r.PathLengthChange, t)
+	testutil.AssertEquals(true, r.PathLengthChange < 0, t)
+}
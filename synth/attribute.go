@@ -0,0 +1,200 @@
+package synth
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// attribute discretizes the values of a column into categories.
+type attribute interface {
+	// categories returns the number of categories.
+	categories() int
+
+	// encode returns the category of a partition.
+	encode(p partition.Partition) (int, error)
+
+	// sample returns a random item from the given category.
+	sample(category int, rnd *rand.Rand) interface{}
+}
+
+// newAttribute creates the attribute of a column. The categories of range and hierarchy
+// columns are taken from the generalizer, which does not depend on the data. The categories
+// of other columns are the given domain, or the distinct items of the column in the table.
+func newAttribute(table *model.Table, colIdx int, bins int, domain []interface{}) (attribute, error) {
+	col := table.GetSchema().Columns[colIdx]
+	if domain != nil {
+		return newItemAttribute(domain), nil
+	}
+	switch g := col.GetGeneralizer().(type) {
+	case *generalization.RangeGeneralizer:
+		return newRangeAttribute(g.Range(), bins), nil
+	case *generalization.HierarchyGeneralizer:
+		return newHierarchyAttribute(g.Hierarchy), nil
+	}
+	var items []interface{}
+	seen := make(map[interface{}]bool)
+	for _, row := range table.GetRows() {
+		item, ok := row.Data[colIdx].(*partition.Item)
+		if !ok {
+			return nil, fmt.Errorf("column %s: unsupported partition %v", col.GetName(), row.Data[colIdx])
+		}
+		if !seen[item.GetItem()] {
+			seen[item.GetItem()] = true
+			items = append(items, item.GetItem())
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return fmt.Sprint(items[i]) < fmt.Sprint(items[j])
+	})
+	return newItemAttribute(items), nil
+}
+
+// rangeAttribute splits the range of a range generalizer into buckets.
+type rangeAttribute struct {
+	buckets []partition.Range
+}
+
+func newRangeAttribute(r partition.Range, bins int) *rangeAttribute {
+	buckets := []partition.Range{r}
+	for len(buckets) < bins {
+		var next []partition.Range
+		for _, b := range buckets {
+			if b.CanSplit() {
+				r1, r2 := b.Split()
+				next = append(next, r1, r2)
+			} else {
+				next = append(next, b)
+			}
+		}
+		if len(next) == len(buckets) {
+			break
+		}
+		buckets = next
+	}
+	return &rangeAttribute{buckets: buckets}
+}
+
+func (a *rangeAttribute) categories() int {
+	return len(a.buckets)
+}
+
+// encode returns the bucket containing the midpoint of the partition, or the closest bucket.
+func (a *rangeAttribute) encode(p partition.Partition) (int, error) {
+	r, ok := p.(partition.Range)
+	if !ok {
+		return 0, fmt.Errorf("not a range: %v", p)
+	}
+	v := (r.Min() + r.Max()) / 2
+	best := 0
+	bestDist := math.Inf(1)
+	for i, b := range a.buckets {
+		dist := math.Max(b.Min()-v, v-b.Max())
+		if dist <= 0 {
+			return i, nil
+		}
+		if dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best, nil
+}
+
+func (a *rangeAttribute) sample(category int, rnd *rand.Rand) interface{} {
+	b := a.buckets[category]
+	if _, ok := b.(*partition.IntRange); ok {
+		min, max := int(b.Min()), int(b.Max())
+		return min + rnd.Intn(max-min+1)
+	}
+	return b.Min() + rnd.Float64()*(b.Max()-b.Min())
+}
+
+// hierarchyAttribute uses the leaves of a generalization hierarchy as categories.
+type hierarchyAttribute struct {
+	leaves []partition.Partition
+}
+
+func newHierarchyAttribute(h hierarchy.Hierarchy) *hierarchyAttribute {
+	a := &hierarchyAttribute{}
+	var collect func(h hierarchy.Hierarchy)
+	collect = func(h hierarchy.Hierarchy) {
+		if len(h.Children()) == 0 {
+			a.leaves = append(a.leaves, h.Partition())
+		}
+		for _, child := range h.Children() {
+			collect(child)
+		}
+	}
+	collect(h)
+	return a
+}
+
+func (a *hierarchyAttribute) categories() int {
+	return len(a.leaves)
+}
+
+// encode returns the leaf equal to the partition, or the first leaf contained in it.
+func (a *hierarchyAttribute) encode(p partition.Partition) (int, error) {
+	for i, leaf := range a.leaves {
+		if leaf.Equals(p) || p.ContainsPartition(leaf) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("partition not in hierarchy: %v", p)
+}
+
+func (a *hierarchyAttribute) sample(category int, rnd *rand.Rand) interface{} {
+	leaf := a.leaves[category]
+	set, ok := leaf.(*partition.Set)
+	if !ok {
+		return leaf.String()
+	}
+	items := make([]interface{}, 0, len(set.Items))
+	for item := range set.Items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return fmt.Sprint(items[i]) < fmt.Sprint(items[j])
+	})
+	return items[rnd.Intn(len(items))]
+}
+
+// itemAttribute uses a list of items as categories.
+type itemAttribute struct {
+	items   []interface{}
+	indices map[interface{}]int
+}
+
+func newItemAttribute(items []interface{}) *itemAttribute {
+	a := &itemAttribute{items: items, indices: make(map[interface{}]int)}
+	for i, item := range items {
+		a.indices[item] = i
+	}
+	return a
+}
+
+func (a *itemAttribute) categories() int {
+	return len(a.items)
+}
+
+func (a *itemAttribute) encode(p partition.Partition) (int, error) {
+	item, ok := p.(*partition.Item)
+	if !ok {
+		return 0, fmt.Errorf("not an item: %v", p)
+	}
+	i, ok := a.indices[item.GetItem()]
+	if !ok {
+		return 0, fmt.Errorf("item not in domain: %v", item)
+	}
+	return i, nil
+}
+
+func (a *itemAttribute) sample(category int, _ *rand.Rand) interface{} {
+	return a.items[category]
+}
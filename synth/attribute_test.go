@@ -0,0 +1,91 @@
+package synth
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestRangeAttribute(t *testing.T) {
+
+	t.Run("int range", func(t *testing.T) {
+		a := newRangeAttribute(partition.NewIntRange(0, 99), 4)
+		testutil.AssertEquals(4, a.categories(), t)
+		c, err := a.encode(partition.NewIntRange(30, 30))
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(1, c, t)
+		c, _ = a.encode(partition.NewIntRange(200, 200))
+		testutil.AssertEquals(3, c, t)
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 100; i++ {
+			v := a.sample(1, rnd).(int)
+			if v < 25 || v > 49 {
+				t.Errorf("sampled value outside of bucket: %d", v)
+			}
+		}
+	})
+
+	t.Run("float range", func(t *testing.T) {
+		a := newRangeAttribute(partition.NewFloatRange(0, 1), 2)
+		testutil.AssertEquals(2, a.categories(), t)
+		c, _ := a.encode(partition.NewFloatRange(0.6, 0.8))
+		testutil.AssertEquals(1, c, t)
+		v := a.sample(0, rand.New(rand.NewSource(1))).(float64)
+		if v < 0 || v > 0.5 {
+			t.Errorf("sampled value outside of bucket: %v", v)
+		}
+	})
+
+	t.Run("range smaller than bins", func(t *testing.T) {
+		a := newRangeAttribute(partition.NewIntRange(0, 2), 16)
+		testutil.AssertEquals(3, a.categories(), t)
+	})
+
+	t.Run("not a range", func(t *testing.T) {
+		a := newRangeAttribute(partition.NewIntRange(0, 2), 16)
+		_, err := a.encode(partition.NewItem(1))
+		testutil.AssertNotNil(err, t)
+	})
+}
+
+func TestHierarchyAttribute(t *testing.T) {
+	a := newHierarchyAttribute(hierarchy.GetGradeHierarchy())
+	testutil.AssertEquals(9, a.categories(), t)
+	c, err := a.encode(partition.NewSet("B"))
+	testutil.AssertNil(err, t)
+	testutil.AssertEquals("B", a.sample(c, rand.New(rand.NewSource(1))), t)
+	_, err = a.encode(partition.NewSet("D"))
+	testutil.AssertNotNil(err, t)
+}
+
+func TestItemAttribute(t *testing.T) {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Gender", &generalization.Suppressor{}),
+		},
+	})
+	table.AddRow("male")
+	table.AddRow("female")
+	table.AddRow("male")
+
+	t.Run("items from table", func(t *testing.T) {
+		a, err := newAttribute(table, 0, 16, nil)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(2, a.categories(), t)
+		c, _ := a.encode(partition.NewItem("male"))
+		testutil.AssertEquals("male", a.sample(c, nil), t)
+	})
+
+	t.Run("items from domain", func(t *testing.T) {
+		a, err := newAttribute(table, 0, 16, []interface{}{"male", "female", "other"})
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(3, a.categories(), t)
+		_, err = a.encode(partition.NewItem("unknown"))
+		testutil.AssertNotNil(err, t)
+	})
+}
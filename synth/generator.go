@@ -0,0 +1,242 @@
+package synth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gar-r/k-anon/dp"
+	"github.com/gar-r/k-anon/model"
+)
+
+// Generator learns the distribution of a table with differential privacy, and samples
+// synthetic tables from it. The distribution is approximated by a Bayesian network, in
+// which each column depends on at most one other column (PrivBayes with degree 1):
+//   - half of Epsilon is spent on choosing the parent of each column with the
+//     exponential mechanism, scored by the mutual information of the columns
+//   - the other half is spent on the Laplace noise added to the marginal tables of
+//     the first column, and of each other column together with its parent
+//
+// Range columns are split into at most Bins buckets (defaults to 16), and hierarchy
+// columns use the leaves of the hierarchy as categories. Other columns use the items in
+// Domains, keyed by column name, or the distinct items in the table. Items taken from
+// the table are not protected by differential privacy, so set Domains for such columns,
+// when the set of their items is sensitive. The number of rows is treated as public.
+// Rand is the source of randomness (defaults to a time-seeded source).
+type Generator struct {
+	Epsilon float64
+	Bins    int
+	Domains map[string][]interface{}
+	Rand    *rand.Rand
+}
+
+// Model is a learned distribution of a table.
+type Model struct {
+	schema     *model.Schema
+	attributes []attribute
+	order      []int       // column indices in the order of sampling
+	parents    []int       // parent column index of each column, or -1
+	marginals  [][]float64 // distribution of the first column, conditional distributions of the others
+	rnd        *rand.Rand
+}
+
+// Synthesize learns the distribution of the table, and samples n synthetic rows from it.
+func (g *Generator) Synthesize(table *model.Table, n int) (*model.Table, error) {
+	m, err := g.Fit(table)
+	if err != nil {
+		return nil, err
+	}
+	return m.Sample(n), nil
+}
+
+// Fit learns the distribution of the table.
+func (g *Generator) Fit(table *model.Table) (*Model, error) {
+	if g.Epsilon <= 0 {
+		return nil, fmt.Errorf("invalid ε: %v", g.Epsilon)
+	}
+	columns := len(table.GetSchema().Columns)
+	rows := len(table.GetRows())
+	if columns == 0 || rows == 0 {
+		return nil, errors.New("cannot learn the distribution of an empty table")
+	}
+	m := &Model{
+		schema:    table.GetSchema(),
+		parents:   make([]int, columns),
+		marginals: make([][]float64, columns),
+		rnd:       g.rand(),
+	}
+	data := make([][]int, columns)
+	for colIdx, col := range table.GetSchema().Columns {
+		a, err := newAttribute(table, colIdx, g.bins(), g.Domains[col.GetName()])
+		if err != nil {
+			return nil, err
+		}
+		m.attributes = append(m.attributes, a)
+		data[colIdx] = make([]int, rows)
+		for rowIdx, row := range table.GetRows() {
+			if data[colIdx][rowIdx], err = a.encode(row.Data[colIdx]); err != nil {
+				return nil, fmt.Errorf("column %s: %w", col.GetName(), err)
+			}
+		}
+	}
+	structureEpsilon, marginalEpsilon := g.Epsilon/2, g.Epsilon/2
+	if columns == 1 {
+		structureEpsilon, marginalEpsilon = 0, g.Epsilon
+	}
+	m.learnStructure(data, structureEpsilon)
+	m.learnMarginals(data, marginalEpsilon)
+	return m, nil
+}
+
+// learnStructure picks the parent of each column from the columns before it in the
+// order of sampling, with the exponential mechanism. The first column in the order is
+// picked at random, the others are picked in the order of the schema.
+func (m *Model) learnStructure(data [][]int, epsilon float64) {
+	columns := len(data)
+	first := m.rnd.Intn(columns)
+	m.order = []int{first}
+	m.parents[first] = -1
+	for colIdx := 0; colIdx < columns; colIdx++ {
+		if colIdx != first {
+			m.order = append(m.order, colIdx)
+		}
+	}
+	n := float64(len(data[0]))
+	sensitivity := miSensitivity(n)
+	perSelection := epsilon / float64(columns-1)
+	for i := 1; i < len(m.order); i++ {
+		child := m.order[i]
+		candidates := m.order[:i]
+		scores := make([]float64, len(candidates))
+		for j, parent := range candidates {
+			joint := m.count(data, child, parent)
+			scores[j] = mutualInformation(joint, m.attributes[child].categories(), n)
+		}
+		m.parents[child] = candidates[dp.Exponential(scores, perSelection, sensitivity, m.rnd)]
+	}
+}
+
+// learnMarginals computes the noisy marginal of the first column, and the noisy joint
+// distribution of each other column with its parent, which is turned into a conditional
+// distribution. Each row is counted once in each of the marginals, so their privacy
+// losses add up.
+func (m *Model) learnMarginals(data [][]int, epsilon float64) {
+	mechanism, _ := dp.NewLaplace(epsilon / float64(len(data)))
+	for _, colIdx := range m.order {
+		var counts []float64
+		if m.parents[colIdx] < 0 {
+			counts = make([]float64, m.attributes[colIdx].categories())
+			for _, c := range data[colIdx] {
+				counts[c]++
+			}
+		} else {
+			counts = m.count(data, colIdx, m.parents[colIdx])
+		}
+		for i := range counts {
+			counts[i] = math.Max(0, mechanism.Randomize(counts[i], 1, 1, m.rnd))
+		}
+		m.marginals[colIdx] = counts
+	}
+}
+
+// count returns the joint counts of a child and a parent column, indexed by parent*children+child.
+func (m *Model) count(data [][]int, child, parent int) []float64 {
+	children := m.attributes[child].categories()
+	counts := make([]float64, children*m.attributes[parent].categories())
+	for rowIdx := range data[child] {
+		counts[data[parent][rowIdx]*children+data[child][rowIdx]]++
+	}
+	return counts
+}
+
+// Sample returns a table with n rows sampled from the learned distribution,
+// with the same schema as the learned table.
+func (m *Model) Sample(n int) *model.Table {
+	table := model.NewTable(m.schema)
+	for i := 0; i < n; i++ {
+		categories := make([]int, len(m.attributes))
+		for _, colIdx := range m.order {
+			counts := m.marginals[colIdx]
+			if parent := m.parents[colIdx]; parent >= 0 {
+				children := m.attributes[colIdx].categories()
+				offset := categories[parent] * children
+				counts = counts[offset : offset+children]
+			}
+			categories[colIdx] = pick(counts, m.rnd)
+		}
+		items := make([]interface{}, len(m.attributes))
+		for colIdx, a := range m.attributes {
+			items[colIdx] = a.sample(categories[colIdx], m.rnd)
+		}
+		table.AddRow(items...)
+	}
+	return table
+}
+
+func (g *Generator) bins() int {
+	if g.Bins <= 0 {
+		return 16
+	}
+	return g.Bins
+}
+
+func (g *Generator) rand() *rand.Rand {
+	if g.Rand == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return g.Rand
+}
+
+// pick returns a random index with probability proportional to the given weights,
+// or a uniformly random index, when all weights are zero.
+func pick(weights []float64, rnd *rand.Rand) int {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return rnd.Intn(len(weights))
+	}
+	r := rnd.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// mutualInformation returns the mutual information of two columns from their joint counts,
+// indexed by parent*children+child.
+func mutualInformation(joint []float64, children int, n float64) float64 {
+	parents := make([]float64, len(joint)/children)
+	childs := make([]float64, children)
+	for i, c := range joint {
+		parents[i/children] += c
+		childs[i%children] += c
+	}
+	return entropy(parents, n) + entropy(childs, n) - entropy(joint, n)
+}
+
+func entropy(counts []float64, n float64) float64 {
+	var h float64
+	for _, c := range counts {
+		if c > 0 {
+			p := c / n
+			h -= p * math.Log(p)
+		}
+	}
+	return h
+}
+
+// miSensitivity returns the sensitivity of the mutual information of two columns
+// in a table with n rows (Lemma 4.1 of PrivBayes).
+func miSensitivity(n float64) float64 {
+	if n < 2 {
+		return math.Log(2)
+	}
+	return 2/n*math.Log((n+1)/2) + (n-1)/n*math.Log((n+1)/(n-1))
+}
@@ -0,0 +1,102 @@
+package synth
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+// getCorrelatedTable returns a table, in which the grade depends on the status,
+// and the age is independent from the other columns.
+func getCorrelatedTable(n int) *model.Table {
+	rnd := rand.New(rand.NewSource(1))
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Status", nil),
+			model.NewColumn("Age", generalization.NewIntRangeGeneralizer(0, 99)),
+			model.NewColumn("Grade", generalization.ExampleGradeGeneralizer()),
+		},
+	})
+	for i := 0; i < n; i++ {
+		if rnd.Float64() < 0.3 {
+			table.AddRow("employee", rnd.Intn(100), "A")
+		} else {
+			table.AddRow("client", rnd.Intn(100), "C")
+		}
+	}
+	return table
+}
+
+func TestGenerator_Synthesize(t *testing.T) {
+
+	t.Run("keeps marginals and correlations", func(t *testing.T) {
+		table := getCorrelatedTable(2000)
+		g := &Generator{Epsilon: 100, Rand: rand.New(rand.NewSource(2))}
+		synthetic, err := g.Synthesize(table, 2000)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(table.GetSchema(), synthetic.GetSchema(), t)
+		testutil.AssertEquals(2000, len(synthetic.GetRows()), t)
+		employees, consistent := 0, 0
+		for _, row := range synthetic.GetRows() {
+			status := row.Data[0].String()
+			if status == "employee" {
+				employees++
+			}
+			if (status == "employee") == row.Data[2].Equals(partition.NewSet("A")) {
+				consistent++
+			}
+			if _, ok := row.Data[1].(*partition.IntRange); !ok {
+				t.Fatalf("expected int range in age column, got %v", row.Data[1])
+			}
+		}
+		if math.Abs(float64(employees)/2000-0.3) > 0.05 {
+			t.Errorf("expected about 30%% employees, got %d", employees)
+		}
+		if float64(consistent)/2000 < 0.95 {
+			t.Errorf("expected correlation between status and grade, got %d consistent rows", consistent)
+		}
+	})
+
+	t.Run("same seed gives same table", func(t *testing.T) {
+		table := getCorrelatedTable(100)
+		t1, _ := (&Generator{Epsilon: 1, Rand: rand.New(rand.NewSource(3))}).Synthesize(table, 50)
+		t2, _ := (&Generator{Epsilon: 1, Rand: rand.New(rand.NewSource(3))}).Synthesize(table, 50)
+		testutil.AssertEquals(t1.String(), t2.String(), t)
+	})
+
+	t.Run("single column", func(t *testing.T) {
+		table := model.NewTable(&model.Schema{
+			Columns: []*model.Column{model.NewColumn("Status", nil)},
+		})
+		table.AddRow("employee")
+		synthetic, err := (&Generator{Epsilon: 1}).Synthesize(table, 3)
+		testutil.AssertNil(err, t)
+		testutil.AssertEquals(3, len(synthetic.GetRows()), t)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := (&Generator{Epsilon: 0}).Fit(getCorrelatedTable(10))
+		testutil.AssertNotNil(err, t)
+		_, err = (&Generator{Epsilon: 1}).Fit(model.NewTable(&model.Schema{}))
+		testutil.AssertNotNil(err, t)
+		_, err = (&Generator{
+			Epsilon: 1,
+			Domains: map[string][]interface{}{"Status": {"employee"}},
+		}).Fit(getCorrelatedTable(10))
+		testutil.AssertNotNil(err, t)
+	})
+}
+
+func TestMutualInformation(t *testing.T) {
+	independent := []float64{25, 25, 25, 25}
+	testutil.AssertEquals(true, math.Abs(mutualInformation(independent, 2, 100)) < 1e-12, t)
+	dependent := []float64{50, 0, 0, 50}
+	testutil.AssertEquals(true, math.Abs(mutualInformation(dependent, 2, 100)-math.Log(2)) < 1e-12, t)
+}
@@ -0,0 +1,119 @@
+package kanon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+)
+
+// TrajectoryAnonymizer anonymizes a trajectory column of the Table (see model.NewTrajectoryColumn),
+// so that each released trajectory is identical to the trajectories of at least K-1 other rows.
+// This follows the idea of (k,δ)-anonymity from Abul, Bonchi and Nanni: "Never Walk Alone", where
+// the trajectories of a cluster are moved into a common cylinder of radius δ. Here the cylinder is
+// given by the place and time generalizers of the column: the trajectories are clustered with greedy
+// k-member clustering, and the points of a cluster are generalized into common areas and time intervals.
+// Trajectories of different length in a cluster are aligned to the shortest one, by merging consecutive
+// points of the longer ones into a single generalized point.
+type TrajectoryAnonymizer struct {
+	K      int
+	Table  *model.Table
+	Column string
+}
+
+// Anonymize clusters the trajectories of the column, and replaces them in place with the generalized
+// trajectory of their cluster. Each cluster contains between K and 2K-1 rows.
+func (a *TrajectoryAnonymizer) Anonymize() error {
+	colIdx, err := a.validate()
+	if err != nil {
+		return err
+	}
+	g := a.Table.GetSchema().Columns[colIdx].GetTrajectoryGeneralizer()
+	trajectories := make([]*partition.Trajectory, len(a.Table.GetRows()))
+	for i, row := range a.Table.GetRows() {
+		t, ok := row.Data[colIdx].(*partition.Trajectory)
+		if !ok || len(t.Points) == 0 {
+			return fmt.Errorf("row %d: column %s must contain a non-empty trajectory, got %v", i, a.Column, row.Data[colIdx])
+		}
+		trajectories[i] = t
+	}
+	clusters, err := kMemberClustering(trajectories, a.K, func(t ...*partition.Trajectory) (*partition.Trajectory, float64, error) {
+		return mergeTrajectories(g, t...)
+	})
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		members := make([]*partition.Trajectory, len(cluster))
+		for i, idx := range cluster {
+			members[i] = trajectories[idx]
+		}
+		merged, _, err := mergeTrajectories(g, members...)
+		if err != nil {
+			return err
+		}
+		for _, idx := range cluster {
+			a.Table.GetRows()[idx].Data[colIdx] = merged
+		}
+	}
+	return nil
+}
+
+func (a *TrajectoryAnonymizer) validate() (int, error) {
+	if a.K < 1 {
+		return 0, fmt.Errorf("invalid value for K: %d", a.K)
+	}
+	if a.Table == nil {
+		return 0, errors.New("missing table")
+	}
+	colIdx := a.Table.GetSchema().IndexOf(a.Column)
+	if colIdx < 0 {
+		return 0, fmt.Errorf("unknown column: %s", a.Column)
+	}
+	if !a.Table.GetSchema().Columns[colIdx].IsTrajectory() {
+		return 0, fmt.Errorf("column %s is not a trajectory column", a.Column)
+	}
+	if len(a.Table.GetRows()) < a.K {
+		return 0, fmt.Errorf("cannot anonymize %d rows with K=%d", len(a.Table.GetRows()), a.K)
+	}
+	return colIdx, nil
+}
+
+// mergeTrajectories generalizes the trajectories into a single trajectory, which contains each of them
+// after aligning them to the length of the shortest one. It also returns the information loss between 0
+// and 1, which is the average generalization level of the points, adjusted by the ratio of merged points.
+func mergeTrajectories(g *generalization.TrajectoryGeneralizer, trajectories ...*partition.Trajectory) (*partition.Trajectory, float64, error) {
+	length, total := math.MaxInt, 0
+	for _, t := range trajectories {
+		length = min(length, len(t.Points))
+		total += len(t.Points)
+	}
+	points := make([]partition.Point, length)
+	pointLoss := 0.0
+	for j := range points {
+		var places, times []partition.Partition
+		for _, t := range trajectories {
+			n := len(t.Points)
+			for _, point := range t.Points[j*n/length : (j+1)*n/length] {
+				places = append(places, point.Place)
+				times = append(times, point.Time)
+			}
+		}
+		place, placeLevel, err := commonGeneralization(g.Place, places)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot generalize places: %w", err)
+		}
+		time, timeLevel, err := commonGeneralization(g.Time, times)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot generalize times: %w", err)
+		}
+		points[j] = partition.Point{Place: place, Time: time}
+		pointLoss += (levelLoss(g.Place, placeLevel) + levelLoss(g.Time, timeLevel)) / 2
+	}
+	pointLoss /= float64(length)
+	merged := 1 - float64(length*len(trajectories))/float64(total)
+	return partition.NewTrajectory(points...), merged + (1-merged)*pointLoss, nil
+}
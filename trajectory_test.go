@@ -0,0 +1,140 @@
+package kanon
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/generalization"
+	"github.com/gar-r/k-anon/hierarchy"
+	"github.com/gar-r/k-anon/model"
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func getPlaceHierarchy() hierarchy.Hierarchy {
+	h, _ := hierarchy.Build(partition.NewSet("home", "school", "office", "gym", "park", "mall"),
+		hierarchy.N(partition.NewSet("home", "school", "office"),
+			hierarchy.N(partition.NewSet("home")),
+			hierarchy.N(partition.NewSet("school")),
+			hierarchy.N(partition.NewSet("office"))),
+		hierarchy.N(partition.NewSet("gym", "park", "mall"),
+			hierarchy.N(partition.NewSet("gym")),
+			hierarchy.N(partition.NewSet("park")),
+			hierarchy.N(partition.NewSet("mall"))))
+	return h
+}
+
+func getMobilityTable() *model.Table {
+	table := model.NewTable(&model.Schema{
+		Columns: []*model.Column{
+			model.NewColumn("Name", nil),
+			model.NewTrajectoryColumn("Route",
+				&generalization.HierarchyGeneralizer{Hierarchy: getPlaceHierarchy()},
+				generalization.NewIntRangeGeneralizer(0, 23)),
+		},
+	})
+	type v = generalization.Visit
+	table.AddRow("Alice", []v{{Place: "home", Time: 7}, {Place: "office", Time: 9}, {Place: "home", Time: 18}})
+	table.AddRow("Bob", []v{{Place: "home", Time: 8}, {Place: "school", Time: 9}, {Place: "home", Time: 17}})
+	table.AddRow("Carol", []v{{Place: "home", Time: 7}, {Place: "office", Time: 8}, {Place: "gym", Time: 19}})
+	table.AddRow("Dave", []v{{Place: "park", Time: 10}, {Place: "mall", Time: 14}})
+	table.AddRow("Eve", []v{{Place: "gym", Time: 11}, {Place: "mall", Time: 15}})
+	table.AddRow("Frank", []v{{Place: "park", Time: 10}, {Place: "gym", Time: 12}, {Place: "mall", Time: 13}, {Place: "park", Time: 15}})
+	table.AddRow("Grace", []v{{Place: "home", Time: 6}, {Place: "office", Time: 9}, {Place: "home", Time: 20}})
+	return table
+}
+
+func TestTrajectoryAnonymizer_Anonymize(t *testing.T) {
+
+	t.Run("k-anonymity", func(t *testing.T) {
+		for _, k := range []int{1, 2, 3, 7} {
+			original := getMobilityTable()
+			table := getMobilityTable()
+			anon := &TrajectoryAnonymizer{K: k, Table: table, Column: "Route"}
+			if err := anon.Anonymize(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertTrajectoryAnonymity(table, 1, k, t)
+			for i, row := range table.GetRows() {
+				assertTrajectoryCovers(row.Data[1].(*partition.Trajectory), original.GetRows()[i].Data[1].(*partition.Trajectory), t)
+			}
+		}
+	})
+
+	t.Run("similar trajectories are clustered", func(t *testing.T) {
+		table := getMobilityTable()
+		anon := &TrajectoryAnonymizer{K: 2, Table: table, Column: "Route"}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows := table.GetRows()
+		testutil.AssertEquals(true, rows[3].Data[1].Equals(rows[4].Data[1]), t)
+		testutil.AssertEquals(false, rows[0].Data[1].Equals(rows[3].Data[1]), t)
+	})
+
+	t.Run("K=1 keeps the trajectories", func(t *testing.T) {
+		table := getMobilityTable()
+		anon := &TrajectoryAnonymizer{K: 1, Table: table, Column: "Route"}
+		if err := anon.Anonymize(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals(getMobilityTable().String(), table.String(), t)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		tests := []struct {
+			name string
+			anon *TrajectoryAnonymizer
+		}{
+			{"invalid K", &TrajectoryAnonymizer{K: 0, Table: getMobilityTable(), Column: "Route"}},
+			{"missing table", &TrajectoryAnonymizer{K: 2, Column: "Route"}},
+			{"unknown column", &TrajectoryAnonymizer{K: 2, Table: getMobilityTable(), Column: "Missing"}},
+			{"not a trajectory column", &TrajectoryAnonymizer{K: 2, Table: getMobilityTable(), Column: "Name"}},
+			{"too few rows", &TrajectoryAnonymizer{K: 8, Table: getMobilityTable(), Column: "Route"}},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if err := test.anon.Anonymize(); err == nil {
+					t.Errorf("expected error")
+				}
+			})
+		}
+	})
+
+	t.Run("unknown place", func(t *testing.T) {
+		table := getMobilityTable()
+		table.AddRow("Heidi", []generalization.Visit{{Place: "airport", Time: 5}})
+		anon := &TrajectoryAnonymizer{K: 2, Table: table, Column: "Route"}
+		if err := anon.Anonymize(); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func assertTrajectoryAnonymity(table *model.Table, colIdx, k int, t *testing.T) {
+	t.Helper()
+	for i, r1 := range table.GetRows() {
+		count := 0
+		for _, r2 := range table.GetRows() {
+			if r1.Data[colIdx].Equals(r2.Data[colIdx]) {
+				count++
+			}
+		}
+		if count < k {
+			t.Errorf("trajectory of row %d is shared by %d rows, expected at least %d", i, count, k)
+		}
+	}
+}
+
+// assertTrajectoryCovers checks, that each point of the original trajectory is contained by the
+// point of the generalized trajectory, which it was merged into.
+func assertTrajectoryCovers(generalized, original *partition.Trajectory, t *testing.T) {
+	t.Helper()
+	m, n := len(generalized.Points), len(original.Points)
+	for j, point := range generalized.Points {
+		for _, p := range original.Points[j*n/m : (j+1)*n/m] {
+			if !generalized.Contains(p) || !(point.Place.Equals(p.Place) || point.Place.ContainsPartition(p.Place)) {
+				t.Errorf("generalized trajectory %v does not cover %v", generalized, original)
+			}
+		}
+	}
+}
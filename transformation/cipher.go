@@ -0,0 +1,49 @@
+package transformation
+
+import (
+	"errors"
+	"fmt"
+)
+
+const maxRadix = 1 << 16
+
+// Cipher is a format-preserving block cipher operating on numeral strings.
+// A numeral string is a sequence of integers, each of them in the range [0, radix).
+// The ciphertext of a numeral string is a numeral string of the same length and radix.
+type Cipher interface {
+	// Encrypt encrypts the numeral string x using the given tweak.
+	Encrypt(x []int, tweak []byte) ([]int, error)
+
+	// Decrypt decrypts the numeral string x using the given tweak.
+	Decrypt(x []int, tweak []byte) ([]int, error)
+
+	// Radix returns the base of the numeral strings handled by the cipher.
+	Radix() int
+}
+
+func validateRadix(radix int) error {
+	if radix < 2 || radix > maxRadix {
+		return fmt.Errorf("radix must be between 2 and %d, got %d", maxRadix, radix)
+	}
+	return nil
+}
+
+func validateKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	}
+	return errors.New("key must be 128, 192 or 256 bits long")
+}
+
+func validateNumerals(x []int, radix, minLen, maxLen int) error {
+	if len(x) < minLen || len(x) > maxLen {
+		return fmt.Errorf("numeral string length must be between %d and %d, got %d", minLen, maxLen, len(x))
+	}
+	for _, d := range x {
+		if d < 0 || d >= radix {
+			return fmt.Errorf("numeral %d is out of range for radix %d", d, radix)
+		}
+	}
+	return nil
+}
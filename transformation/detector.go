@@ -0,0 +1,231 @@
+package transformation
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Types of the findings reported by the built-in detectors.
+const (
+	Email      = "EMAIL"
+	Phone      = "PHONE"
+	IBAN       = "IBAN"
+	CreditCard = "CREDIT_CARD"
+	IPAddress  = "IP_ADDRESS"
+	Date       = "DATE"
+	Name       = "NAME"
+)
+
+// Finding is a piece of sensitive text, found at text[Start:End] by a Detector.
+type Finding struct {
+	Type  string
+	Start int
+	End   int
+	Text  string
+}
+
+// Detector finds sensitive content, such as email addresses or names in a text.
+type Detector interface {
+	// Detect returns the findings in the text, ordered by their position.
+	Detect(text string) []Finding
+}
+
+// RegexDetector reports the matches of a regular expression, which pass the optional validation.
+type RegexDetector struct {
+	Type     string
+	Pattern  *regexp.Regexp
+	Validate func(match string) bool
+}
+
+// Detect returns the valid matches of the pattern in the text.
+func (d *RegexDetector) Detect(text string) []Finding {
+	var findings []Finding
+	for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		if d.Validate == nil || d.Validate(match) {
+			findings = append(findings, Finding{Type: d.Type, Start: loc[0], End: loc[1], Text: match})
+		}
+	}
+	return findings
+}
+
+// EmailDetector detects email addresses.
+func EmailDetector() *RegexDetector {
+	return &RegexDetector{
+		Type:    Email,
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+}
+
+// PhoneDetector detects phone numbers with 7 to 15 digits, optionally with a country code, an area code
+// in parentheses, and space, dot or dash separators.
+func PhoneDetector() *RegexDetector {
+	return &RegexDetector{
+		Type:    Phone,
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{1,4}\)[ .-]?)?\d{1,4}(?:[ .-]?\d{2,4}){1,4}`),
+		Validate: func(match string) bool {
+			n := len(digits(match))
+			return n >= 7 && n <= 15
+		},
+	}
+}
+
+// IBANDetector detects international bank account numbers, which pass the mod 97 check.
+func IBANDetector() *RegexDetector {
+	return &RegexDetector{
+		Type:     IBAN,
+		Pattern:  regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`),
+		Validate: validIBAN,
+	}
+}
+
+// CreditCardDetector detects card numbers of 13 to 19 digits, which pass the Luhn check.
+func CreditCardDetector() *RegexDetector {
+	return &RegexDetector{
+		Type:    CreditCard,
+		Pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate: func(match string) bool {
+			return luhn(digits(match))
+		},
+	}
+}
+
+// IPAddressDetector detects IPv4 and IPv6 addresses.
+func IPAddressDetector() *RegexDetector {
+	return &RegexDetector{
+		Type:    IPAddress,
+		Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`),
+		Validate: func(match string) bool {
+			return net.ParseIP(match) != nil
+		},
+	}
+}
+
+// dateLayouts are the date formats recognized by the DateDetector.
+var dateLayouts = []string{"2006-01-02", "2.1.2006", "2/1/2006", "1/2/2006"}
+
+// DateDetector detects valid dates in the formats 2006-01-02, 2.1.2006, 2/1/2006 and 1/2/2006.
+func DateDetector() *RegexDetector {
+	return &RegexDetector{
+		Type:    Date,
+		Pattern: regexp.MustCompile(`\b(?:\d{4}-\d{2}-\d{2}|\d{1,2}[./]\d{1,2}[./]\d{4})\b`),
+		Validate: func(match string) bool {
+			_, ok := parseDate(match)
+			return ok
+		},
+	}
+}
+
+// DefaultDetectors returns the built-in regular expression detectors. When two detectors find the same
+// text, the one earlier in the list wins, so dates and card numbers are not reported as phone numbers.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		EmailDetector(),
+		IBANDetector(),
+		CreditCardDetector(),
+		IPAddressDetector(),
+		DateDetector(),
+		PhoneDetector(),
+	}
+}
+
+// DictionaryDetector detects the words of a dictionary, such as first names or surnames. Words are
+// matched case-insensitively, but only when they start with an upper case letter in the text, so
+// "Mark" is detected, while "mark" is not. Adjacent words separated by spaces are reported as a
+// single finding, so "Mary Ann Smith" is one name.
+type DictionaryDetector struct {
+	Type  string
+	words map[string]bool
+}
+
+var wordPattern = regexp.MustCompile(`\p{L}[\p{L}'-]*`)
+
+// NewDictionaryDetector creates a detector for the given words, reporting findings of the given type.
+func NewDictionaryDetector(findingType string, words ...string) *DictionaryDetector {
+	d := &DictionaryDetector{Type: findingType, words: make(map[string]bool, len(words))}
+	for _, w := range words {
+		d.words[strings.ToLower(w)] = true
+	}
+	return d
+}
+
+// NewNameDetector creates a dictionary detector for names.
+func NewNameDetector(names ...string) *DictionaryDetector {
+	return NewDictionaryDetector(Name, names...)
+}
+
+// Detect returns the dictionary words in the text.
+func (d *DictionaryDetector) Detect(text string) []Finding {
+	var findings []Finding
+	for _, loc := range wordPattern.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		if !unicode.IsUpper([]rune(word)[0]) || !d.words[strings.ToLower(word)] {
+			continue
+		}
+		if n := len(findings); n > 0 && strings.TrimSpace(text[findings[n-1].End:loc[0]]) == "" {
+			findings[n-1].End = loc[1]
+			findings[n-1].Text = text[findings[n-1].Start:loc[1]]
+			continue
+		}
+		findings = append(findings, Finding{Type: d.Type, Start: loc[0], End: loc[1], Text: word})
+	}
+	return findings
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func digits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// luhn returns true, when the digits pass the Luhn checksum.
+func luhn(digits string) bool {
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return len(digits) > 0 && sum%10 == 0
+}
+
+// validIBAN returns true, when the IBAN has a valid length and passes the mod 97 check.
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	rem := 0
+	for _, r := range s[4:] + s[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			rem = (rem*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			rem = (rem*100 + int(r-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return rem == 1
+}
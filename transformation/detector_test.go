@@ -0,0 +1,43 @@
+package transformation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestDetectors(t *testing.T) {
+	tests := []struct {
+		detector Detector
+		text     string
+		expected []string
+	}{
+		{EmailDetector(), "write to john.doe+news@example.co.uk today", []string{"john.doe+news@example.co.uk"}},
+		{EmailDetector(), "no email @ here", nil},
+		{PhoneDetector(), "call +1 (555) 123-4567 or 06 30 123 4567", []string{"+1 (555) 123-4567", "06 30 123 4567"}},
+		{PhoneDetector(), "room 1234", nil},
+		{IBANDetector(), "pay to DE89 3704 0044 0532 0130 00 or GB82WEST12345698765432", []string{"DE89 3704 0044 0532 0130 00", "GB82WEST12345698765432"}},
+		{IBANDetector(), "invalid DE88 3704 0044 0532 0130 00", nil},
+		{CreditCardDetector(), "card 4111 1111 1111 1111, 4111-1111-1111-1112", []string{"4111 1111 1111 1111"}},
+		{IPAddressDetector(), "from 192.168.1.10 and 2001:db8::1, not 999.1.1.1 or 10:30:00", []string{"192.168.1.10", "2001:db8::1"}},
+		{DateDetector(), "born 1985-03-12 or 12.3.1985, not 2020-13-45", []string{"1985-03-12", "12.3.1985"}},
+		{NewNameDetector("John", "Smith", "Mark"), "John Smith met mark and Mark.", []string{"John Smith", "Mark"}},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			var actual []string
+			for _, f := range test.detector.Detect(test.text) {
+				testutil.AssertEquals(f.Text, test.text[f.Start:f.End], t)
+				actual = append(actual, f.Text)
+			}
+			testutil.AssertEquals(fmt.Sprint(test.expected), fmt.Sprint(actual), t)
+		})
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	testutil.AssertEquals(true, luhn("79927398713"), t)
+	testutil.AssertEquals(false, luhn("79927398710"), t)
+	testutil.AssertEquals(false, luhn(""), t)
+}
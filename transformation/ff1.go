@@ -0,0 +1,141 @@
+package transformation
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+const ff1Rounds = 10
+
+// FF1 implements the FF1 format-preserving encryption mode, as specified in
+// NIST SP 800-38G. The tweak can be of arbitrary length, including zero.
+type FF1 struct {
+	block cipher.Block
+	radix int
+	min   int
+}
+
+// NewFF1 creates a new FF1 cipher with the given AES key and radix.
+// The key must be 16, 24 or 32 bytes long.
+func NewFF1(key []byte, radix int) (*FF1, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	if err := validateRadix(radix); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &FF1{block: block, radix: radix, min: minLength(radix)}, nil
+}
+
+// Radix returns the base of the numeral strings handled by the cipher.
+func (c *FF1) Radix() int {
+	return c.radix
+}
+
+// Encrypt encrypts the numeral string x using the given tweak.
+func (c *FF1) Encrypt(x []int, tweak []byte) ([]int, error) {
+	return c.crypt(x, tweak, false)
+}
+
+// Decrypt decrypts the numeral string x using the given tweak.
+func (c *FF1) Decrypt(x []int, tweak []byte) ([]int, error) {
+	return c.crypt(x, tweak, true)
+}
+
+func (c *FF1) crypt(x []int, tweak []byte, decrypt bool) ([]int, error) {
+	// the maximum length is 2^32-1, capped to fit into int on 32-bit targets
+	if err := validateNumerals(x, c.radix, c.min, math.MaxInt32); err != nil {
+		return nil, err
+	}
+	n := len(x)
+	u := n / 2
+	v := n - u
+	a := append([]int(nil), x[:u]...)
+	b := append([]int(nil), x[u:]...)
+	byteLen := (new(big.Int).Sub(pow(c.radix, v), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((byteLen+3)/4) + 4
+	p := c.header(u, n, len(tweak))
+	padding := mod(big.NewInt(int64(-len(tweak)-byteLen-1)), big.NewInt(16)).Int64()
+	q := make([]byte, len(tweak)+int(padding)+1+byteLen)
+	copy(q, tweak)
+	for r := 0; r < ff1Rounds; r++ {
+		i := r
+		if decrypt {
+			i = ff1Rounds - 1 - r
+		}
+		m := u
+		if i%2 == 1 {
+			m = v
+		}
+		src, dst := b, a
+		if decrypt {
+			src, dst = a, b
+		}
+		q[len(tweak)+int(padding)] = byte(i)
+		num(src, c.radix).FillBytes(q[len(q)-byteLen:])
+		y := new(big.Int).SetBytes(c.expand(c.prf(p, q), d))
+		var z *big.Int
+		if decrypt {
+			z = new(big.Int).Sub(num(dst, c.radix), y)
+		} else {
+			z = new(big.Int).Add(num(dst, c.radix), y)
+		}
+		result := str(mod(z, pow(c.radix, m)), c.radix, m)
+		if decrypt {
+			a, b = result, a
+		} else {
+			a, b = b, result
+		}
+	}
+	return append(a, b...), nil
+}
+
+func (c *FF1) header(u, n, t int) []byte {
+	p := make([]byte, aes.BlockSize)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3] = byte(c.radix >> 16)
+	p[4] = byte(c.radix >> 8)
+	p[5] = byte(c.radix)
+	p[6] = 10
+	p[7] = byte(u % 256)
+	binary.BigEndian.PutUint32(p[8:], uint32(n))
+	binary.BigEndian.PutUint32(p[12:], uint32(t))
+	return p
+}
+
+// prf computes the CBC-MAC of p || q with a zero IV.
+func (c *FF1) prf(p, q []byte) []byte {
+	y := make([]byte, aes.BlockSize)
+	c.block.Encrypt(y, p)
+	for i := 0; i < len(q); i += aes.BlockSize {
+		for j := 0; j < aes.BlockSize; j++ {
+			y[j] ^= q[i+j]
+		}
+		c.block.Encrypt(y, y)
+	}
+	return y
+}
+
+// expand extends r to d bytes with encryptions of r xor [j]^16.
+func (c *FF1) expand(r []byte, d int) []byte {
+	s := append([]byte(nil), r...)
+	block := make([]byte, aes.BlockSize)
+	for j := 1; len(s) < d; j++ {
+		copy(block, r)
+		counter := make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(counter[8:], uint64(j))
+		for k := range block {
+			block[k] ^= counter[k]
+		}
+		c.block.Encrypt(block, block)
+		s = append(s, block...)
+	}
+	return s[:d]
+}
@@ -0,0 +1,95 @@
+package transformation
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func TestFF1_Encrypt(t *testing.T) {
+	// sample vectors from NIST SP 800-38G
+	tests := []struct {
+		key, tweak string
+		alphabet   string
+		plain      string
+		cipher     string
+	}{
+		{"2B7E151628AED2A6ABF7158809CF4F3C", "", Digits, "0123456789", "2433477484"},
+		{"2B7E151628AED2A6ABF7158809CF4F3C", "39383736353433323130", Digits, "0123456789", "6124200773"},
+		{"2B7E151628AED2A6ABF7158809CF4F3C", "3737373770717273373737", LowerAlphanumeric, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F", "", Digits, "0123456789", "2830668132"},
+		{"2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", "", Digits, "0123456789", "6657667009"},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("sample #%d", i+1), func(t *testing.T) {
+			c, err := NewFF1(decodeHex(test.key, t), len(test.alphabet))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tr, err := NewFPETransformer(c, test.alphabet, decodeHex(test.tweak, t))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual, err := tr.Encrypt(test.plain)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.cipher != actual {
+				t.Errorf("expected %v, got %v", test.cipher, actual)
+			}
+			decrypted, err := tr.Decrypt(actual)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.plain != decrypted {
+				t.Errorf("expected %v, got %v", test.plain, decrypted)
+			}
+		})
+	}
+}
+
+func TestNewFF1(t *testing.T) {
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := NewFF1([]byte("short"), 10)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+	t.Run("invalid radix", func(t *testing.T) {
+		_, err := NewFF1(make([]byte, 16), 1)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+}
+
+func TestFF1_InvalidInput(t *testing.T) {
+	c, _ := NewFF1(make([]byte, 16), 10)
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := c.Encrypt([]int{1, 2, 3}, nil)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+	t.Run("numeral out of range", func(t *testing.T) {
+		_, err := c.Encrypt([]int{1, 2, 3, 4, 5, 10}, nil)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+}
+
+func decodeHex(s string, t *testing.T) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex string: %v", s)
+	}
+	return b
+}
@@ -0,0 +1,148 @@
+package transformation
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+func TestFF31_Crypt(t *testing.T) {
+	// FF3-1 shares its round function with FF3, the tweak halves are derived differently;
+	// the sample vectors below are the original FF3 samples of NIST SP 800-38G
+	tests := []struct {
+		key, tweak string
+		alphabet   string
+		plain      string
+		cipher     string
+	}{
+		{"EF4359D8D580AA4F7F036D6F04FC6A94", "D8E7920AFA330A73", Digits, "890121234567890000", "750918814058654607"},
+		{"EF4359D8D580AA4F7F036D6F04FC6A94", "9A768A92F60E12D8", Digits, "890121234567890000", "018989839189395384"},
+		{"EF4359D8D580AA4F7F036D6F04FC6A94", "D8E7920AFA330A73", Digits, "89012123456789000000789000000", "48598367162252569629397416226"},
+		{"EF4359D8D580AA4F7F036D6F04FC6A94", "0000000000000000", Digits, "89012123456789000000789000000", "34695224821734535122613701434"},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("sample #%d", i+1), func(t *testing.T) {
+			c, err := NewFF31(decodeHex(test.key, t), len(test.alphabet))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tweak := decodeHex(test.tweak, t)
+			x := toNumerals(test.plain)
+			y, err := c.crypt(x, tweak[:4], tweak[4:], false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := fromNumerals(y); test.cipher != actual {
+				t.Errorf("expected %v, got %v", test.cipher, actual)
+			}
+			z, err := c.crypt(y, tweak[:4], tweak[4:], true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := fromNumerals(z); test.plain != actual {
+				t.Errorf("expected %v, got %v", test.plain, actual)
+			}
+		})
+	}
+}
+
+func TestFF31_Transformer(t *testing.T) {
+	// FF3-1 sample vectors of the NIST ACVP tests for SP 800-38G Revision 1, which use
+	// the 56 bit tweak, so they cover the derivation of the tweak halves as well
+	tests := []struct {
+		key, tweak string
+		alphabet   string
+		plain      string
+		cipher     string
+	}{
+		{"2DE79D232DF5585D68CE47882AE256D6", "CBD09280979564", Digits, "3992520240", "8901801106"},
+		{"01C63017111438F7FC8E24EB16C71AB5", "C4E822DCD09F27", Digits,
+			"60761757463116869318437658042297305934914824457484538562",
+			"35637144092473838892796702739628394376915177448290847293"},
+		{"718385E6542534604419E83CE387A437", "B6F35084FA90E1", "abcdefghijklmnopqrstuvwxyz", "wfmwlrorcd", "ywowehycyd"},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("sample #%d", i+1), func(t *testing.T) {
+			c, err := NewFF31(decodeHex(test.key, t), len(test.alphabet))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tr, err := NewFPETransformer(c, test.alphabet, decodeHex(test.tweak, t))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			p, err := tr.Transform(partition.NewItem(test.plain))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := partition.NewItem(test.cipher); !expected.Equals(p) {
+				t.Errorf("expected %v, got %v", expected, p)
+			}
+			plain, err := tr.Decrypt(test.cipher)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.plain != plain {
+				t.Errorf("expected %v, got %v", test.plain, plain)
+			}
+		})
+	}
+}
+
+func TestFF31_EncryptDecrypt(t *testing.T) {
+	c, err := NewFF31(decodeHex("EF4359D8D580AA4F7F036D6F04FC6A94", t), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tweak := decodeHex("D8E7920AFA330A", t)
+	x := toNumerals("4000001234567899")
+	y, err := c.Encrypt(x, tweak)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(y) != len(x) {
+		t.Errorf("expected length %d, got %d", len(x), len(y))
+	}
+	z, err := c.Decrypt(y, tweak)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fromNumerals(x) != fromNumerals(z) {
+		t.Errorf("expected %v, got %v", fromNumerals(x), fromNumerals(z))
+	}
+}
+
+func TestFF31_InvalidTweak(t *testing.T) {
+	c, _ := NewFF31(make([]byte, 16), 10)
+	_, err := c.Encrypt(toNumerals("123456789"), make([]byte, 8))
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestFF31_TooLong(t *testing.T) {
+	c, _ := NewFF31(make([]byte, 16), 10)
+	x := toNumerals(string(bytes.Repeat([]byte("1"), 100)))
+	_, err := c.Encrypt(x, make([]byte, 7))
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func toNumerals(s string) []int {
+	x := make([]int, len(s))
+	for i, r := range s {
+		x[i] = int(r - '0')
+	}
+	return x
+}
+
+func fromNumerals(x []int) string {
+	b := make([]byte, len(x))
+	for i, d := range x {
+		b[i] = byte('0' + d)
+	}
+	return string(b)
+}
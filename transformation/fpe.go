@@ -0,0 +1,91 @@
+package transformation
+
+import (
+	"fmt"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Commonly used alphabets for format-preserving encryption.
+const (
+	Digits            = "0123456789"
+	LowerAlphanumeric = "0123456789abcdefghijklmnopqrstuvwxyz"
+	Alphanumeric      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// FPETransformer is a Transformer which masks values with format-preserving encryption.
+// Each value is converted to a numeral string using the alphabet, where the radix of
+// the cipher equals the size of the alphabet. Characters which are not part of the
+// alphabet (such as dashes or spaces) are kept in place, so "4111-1111-1111-1111"
+// encrypts to another string of four dash separated groups of four digits.
+type FPETransformer struct {
+	cipher   Cipher
+	tweak    []byte
+	alphabet []rune
+	index    map[rune]int
+}
+
+// NewFPETransformer creates a new FPETransformer from a cipher, an alphabet and a tweak.
+// The alphabet must consist of unique characters, and its length must match the radix of the cipher.
+func NewFPETransformer(c Cipher, alphabet string, tweak []byte) (*FPETransformer, error) {
+	runes := []rune(alphabet)
+	if len(runes) != c.Radix() {
+		return nil, fmt.Errorf("alphabet size %d does not match cipher radix %d", len(runes), c.Radix())
+	}
+	index := make(map[rune]int)
+	for i, r := range runes {
+		if _, exists := index[r]; exists {
+			return nil, fmt.Errorf("duplicate character in alphabet: %q", r)
+		}
+		index[r] = i
+	}
+	return &FPETransformer{
+		cipher:   c,
+		tweak:    tweak,
+		alphabet: runes,
+		index:    index,
+	}, nil
+}
+
+// Encrypt encrypts the alphabet characters of s, keeping all other characters in place.
+func (t *FPETransformer) Encrypt(s string) (string, error) {
+	return t.crypt(s, t.cipher.Encrypt)
+}
+
+// Decrypt decrypts the alphabet characters of s, keeping all other characters in place.
+func (t *FPETransformer) Decrypt(s string) (string, error) {
+	return t.crypt(s, t.cipher.Decrypt)
+}
+
+// Transform encrypts the value of the given Item partition, and returns the result as a new Item.
+// Non-string values are converted to their string representation before encryption.
+func (t *FPETransformer) Transform(p partition.Partition) (partition.Partition, error) {
+	item, success := p.(*partition.Item)
+	if !success {
+		return nil, fmt.Errorf("format-preserving encryption is only supported on items, got %v", p)
+	}
+	s, err := t.Encrypt(fmt.Sprintf("%v", item.GetItem()))
+	if err != nil {
+		return nil, err
+	}
+	return partition.NewItem(s), nil
+}
+
+func (t *FPETransformer) crypt(s string, fn func(x []int, tweak []byte) ([]int, error)) (string, error) {
+	runes := []rune(s)
+	var positions, x []int
+	for i, r := range runes {
+		if d, ok := t.index[r]; ok {
+			positions = append(positions, i)
+			x = append(x, d)
+		}
+	}
+	y, err := fn(x, t.tweak)
+	if err != nil {
+		return "", fmt.Errorf("cannot process %q: %w", s, err)
+	}
+	for i, pos := range positions {
+		runes[pos] = t.alphabet[y[i]]
+	}
+	return string(runes), nil
+}
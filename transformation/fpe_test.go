@@ -0,0 +1,87 @@
+package transformation
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+func TestNewFPETransformer(t *testing.T) {
+	c, _ := NewFF1(make([]byte, 16), 10)
+
+	t.Run("alphabet size mismatch", func(t *testing.T) {
+		_, err := NewFPETransformer(c, LowerAlphanumeric, nil)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+	t.Run("duplicate characters", func(t *testing.T) {
+		_, err := NewFPETransformer(c, "0123456780", nil)
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+}
+
+func TestFPETransformer_Encrypt(t *testing.T) {
+	c, _ := NewFF1(decodeHex("2B7E151628AED2A6ABF7158809CF4F3C", t), 10)
+	tr, _ := NewFPETransformer(c, Digits, []byte("tweak"))
+
+	t.Run("keeps separators", func(t *testing.T) {
+		actual, err := tr.Encrypt("4111-1111-1111-1111")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(actual) != 19 || actual[4] != '-' || actual[9] != '-' || actual[14] != '-' {
+			t.Errorf("format not preserved: %v", actual)
+		}
+		decrypted, _ := tr.Decrypt(actual)
+		if decrypted != "4111-1111-1111-1111" {
+			t.Errorf("expected %v, got %v", "4111-1111-1111-1111", decrypted)
+		}
+	})
+
+	t.Run("too few alphabet characters", func(t *testing.T) {
+		_, err := tr.Encrypt("ab-12")
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+}
+
+func TestFPETransformer_Transform(t *testing.T) {
+	c, _ := NewFF1(decodeHex("2B7E151628AED2A6ABF7158809CF4F3C", t), 10)
+	tr, _ := NewFPETransformer(c, Digits, nil)
+
+	t.Run("transform item", func(t *testing.T) {
+		actual, err := tr.Transform(partition.NewItem("0123456789"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := partition.NewItem("2433477484")
+		if !expected.Equals(actual) {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+	})
+
+	t.Run("transform numeric item", func(t *testing.T) {
+		actual, err := tr.Transform(partition.NewItem(123456789))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(actual.String()) != 9 {
+			t.Errorf("expected 9 digits, got %v", actual)
+		}
+	})
+
+	t.Run("unsupported partition", func(t *testing.T) {
+		_, err := tr.Transform(partition.NewIntRange(1, 5))
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+	})
+
+}
@@ -0,0 +1,72 @@
+package transformation
+
+import (
+	"math/big"
+)
+
+// num returns the number represented by the numeral string x in base radix,
+// where the first numeral is the most significant one.
+func num(x []int, radix int) *big.Int {
+	r := big.NewInt(int64(radix))
+	result := new(big.Int)
+	for _, d := range x {
+		result.Mul(result, r)
+		result.Add(result, big.NewInt(int64(d)))
+	}
+	return result
+}
+
+// str returns the representation of x as a numeral string of length m in base radix.
+func str(x *big.Int, radix, m int) []int {
+	r := big.NewInt(int64(radix))
+	rem := new(big.Int)
+	q := new(big.Int).Set(x)
+	result := make([]int, m)
+	for i := m - 1; i >= 0; i-- {
+		q.DivMod(q, r, rem)
+		result[i] = int(rem.Int64())
+	}
+	return result
+}
+
+// pow returns radix^m.
+func pow(radix, m int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(m)), nil)
+}
+
+// mod returns x mod m, always in the range [0, m).
+func mod(x, m *big.Int) *big.Int {
+	return new(big.Int).Mod(x, m)
+}
+
+// rev returns the numeral string in reverse order.
+func rev(x []int) []int {
+	result := make([]int, len(x))
+	for i, d := range x {
+		result[len(x)-1-i] = d
+	}
+	return result
+}
+
+// revb returns the byte string in reverse order.
+func revb(x []byte) []byte {
+	result := make([]byte, len(x))
+	for i, b := range x {
+		result[len(x)-1-i] = b
+	}
+	return result
+}
+
+// minLength returns the minimum numeral string length for the radix,
+// such that radix^minlen >= 1 000 000, as required by NIST SP 800-38G.
+func minLength(radix int) int {
+	limit := big.NewInt(1000000)
+	n := 1
+	for pow(radix, n).Cmp(limit) < 0 {
+		n++
+	}
+	if n < 2 {
+		n = 2
+	}
+	return n
+}
@@ -0,0 +1,146 @@
+package transformation
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gar-r/k-anon/partition"
+)
+
+// Replacer returns the replacement text of a finding.
+type Replacer func(f Finding) string
+
+// Redactor is a Transformer which replaces the sensitive content of free-text values, found by its
+// detectors. By default each finding is replaced with a placeholder of its type, such as "[EMAIL]".
+// Replacers can be set per finding type to replace the findings with a generalized value instead.
+type Redactor struct {
+	Detectors []Detector
+	Replacers map[string]Replacer
+}
+
+// NewRedactor creates a new Redactor with the given detectors, or with the DefaultDetectors when
+// no detectors are given.
+func NewRedactor(detectors ...Detector) *Redactor {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return &Redactor{Detectors: detectors, Replacers: make(map[string]Replacer)}
+}
+
+// WithReplacer sets the replacer of the given finding type, and returns the redactor.
+func (r *Redactor) WithReplacer(findingType string, replacer Replacer) *Redactor {
+	if r.Replacers == nil {
+		r.Replacers = make(map[string]Replacer)
+	}
+	r.Replacers[findingType] = replacer
+	return r
+}
+
+// Findings returns the findings of all detectors in the text, ordered by their position.
+// Of overlapping findings the longest one is kept, and of findings with the same position
+// the one reported by the earlier detector.
+func (r *Redactor) Findings(text string) []Finding {
+	var all []Finding
+	for _, d := range r.Detectors {
+		all = append(all, d.Detect(text)...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Start != all[j].Start {
+			return all[i].Start < all[j].Start
+		}
+		return all[i].End > all[j].End
+	})
+	var result []Finding
+	for _, f := range all {
+		if n := len(result); n > 0 && f.Start < result[n-1].End {
+			if f.End-f.Start > result[n-1].End-result[n-1].Start {
+				result[n-1] = f
+			}
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// Redact replaces the findings in the text.
+func (r *Redactor) Redact(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, f := range r.Findings(text) {
+		sb.WriteString(text[last:f.Start])
+		replace, ok := r.Replacers[f.Type]
+		if !ok {
+			replace = Placeholder
+		}
+		sb.WriteString(replace(f))
+		last = f.End
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// Transform redacts the value of the given Item partition, and returns the result as a new Item.
+// Non-string values are converted to their string representation before redaction.
+func (r *Redactor) Transform(p partition.Partition) (partition.Partition, error) {
+	item, success := p.(*partition.Item)
+	if !success {
+		return nil, fmt.Errorf("redaction is only supported on items, got %v", p)
+	}
+	return partition.NewItem(r.Redact(fmt.Sprintf("%v", item.GetItem()))), nil
+}
+
+// Placeholder replaces the finding with its type in brackets, such as "[EMAIL]".
+func Placeholder(f Finding) string {
+	return "[" + f.Type + "]"
+}
+
+// GeneralizeEmail keeps only the domain of an email address, such as "*@example.com".
+func GeneralizeEmail(f Finding) string {
+	i := strings.LastIndex(f.Text, "@")
+	if i < 0 {
+		return Placeholder(f)
+	}
+	return "*" + f.Text[i:]
+}
+
+// GeneralizeDate keeps only the year of a date.
+func GeneralizeDate(f Finding) string {
+	t, ok := parseDate(f.Text)
+	if !ok {
+		return Placeholder(f)
+	}
+	return strconv.Itoa(t.Year())
+}
+
+// GeneralizeIPAddress replaces an IP address with its network, using a /24 prefix for IPv4
+// and a /64 prefix for IPv6 addresses, such as "192.168.1.0/24".
+func GeneralizeIPAddress(f Finding) string {
+	ip := net.ParseIP(f.Text)
+	if ip == nil {
+		return Placeholder(f)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// MaskDigits returns a replacer, which replaces all but the last n digits of the finding with '*',
+// keeping the other characters in place, such as "**** **** **** 1111".
+func MaskDigits(n int) Replacer {
+	return func(f Finding) string {
+		keep := len(digits(f.Text)) - n
+		runes := []rune(f.Text)
+		for i, r := range runes {
+			if r >= '0' && r <= '9' && keep > 0 {
+				runes[i] = '*'
+				keep--
+			}
+		}
+		return string(runes)
+	}
+}
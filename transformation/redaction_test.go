@@ -0,0 +1,73 @@
+package transformation
+
+import (
+	"testing"
+
+	"github.com/gar-r/k-anon/partition"
+	"github.com/gar-r/k-anon/testutil"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+
+	t.Run("placeholders", func(t *testing.T) {
+		r := NewRedactor(append(DefaultDetectors(), NewNameDetector("Alice", "Smith"))...)
+		actual := r.Redact("Alice Smith (alice@example.com, +36 1 234 5678) paid with 4111 1111 1111 1111 on 2021-05-04")
+		expected := "[NAME] ([EMAIL], [PHONE]) paid with [CREDIT_CARD] on [DATE]"
+		testutil.AssertEquals(expected, actual, t)
+	})
+
+	t.Run("generalized values", func(t *testing.T) {
+		r := NewRedactor().
+			WithReplacer(Email, GeneralizeEmail).
+			WithReplacer(Date, GeneralizeDate).
+			WithReplacer(IPAddress, GeneralizeIPAddress).
+			WithReplacer(CreditCard, MaskDigits(4))
+		actual := r.Redact("bob@example.com logged in from 192.168.1.10 on 4.5.2021 with 4111-1111-1111-1111")
+		expected := "*@example.com logged in from 192.168.1.0/24 on 2021 with ****-****-****-1111"
+		testutil.AssertEquals(expected, actual, t)
+		testutil.AssertEquals("2001:db8::/64", r.Redact("2001:db8::1"), t)
+	})
+
+	t.Run("no findings", func(t *testing.T) {
+		testutil.AssertEquals("nothing to see", NewRedactor().Redact("nothing to see"), t)
+	})
+
+	t.Run("custom detector", func(t *testing.T) {
+		r := &Redactor{Detectors: []Detector{NewDictionaryDetector("CITY", "Budapest")}}
+		testutil.AssertEquals("lives in [CITY]", r.Redact("lives in Budapest"), t)
+	})
+}
+
+func TestRedactor_Findings(t *testing.T) {
+	r := NewRedactor()
+
+	t.Run("same text is reported by the earlier detector", func(t *testing.T) {
+		findings := r.Findings("on 2021-05-04")
+		testutil.AssertEquals(1, len(findings), t)
+		testutil.AssertEquals(Date, findings[0].Type, t)
+	})
+
+	t.Run("longest overlapping finding wins", func(t *testing.T) {
+		findings := r.Findings("4111 1111 1111 1111")
+		testutil.AssertEquals(1, len(findings), t)
+		testutil.AssertEquals(CreditCard, findings[0].Type, t)
+	})
+}
+
+func TestRedactor_Transform(t *testing.T) {
+	r := NewRedactor()
+
+	t.Run("item", func(t *testing.T) {
+		p, err := r.Transform(partition.NewItem("mail me at a@b.io"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		testutil.AssertEquals("mail me at [EMAIL]", p.String(), t)
+	})
+
+	t.Run("not an item", func(t *testing.T) {
+		if _, err := r.Transform(partition.NewSet("a@b.io")); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
@@ -0,0 +1,12 @@
+package transformation
+
+import "github.com/gar-r/k-anon/partition"
+
+// Transformer encapsulates a value transformation procedure.
+// Unlike a generalizer, a transformer replaces each value on its own, without
+// considering the other values of the column. Transformers are typically used on
+// direct identifiers, which have to be masked, but must keep their format.
+type Transformer interface {
+	// Transform returns the transformed version of the given partition.
+	Transform(p partition.Partition) (partition.Partition, error)
+}